@@ -0,0 +1,345 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"github.com/glenn-brown/ordinal"
+	"iter"
+	"sort"
+)
+
+// FromSeq returns a new treap holding the values produced by seq.  If
+// sorted is true, the values must arrive in sorted order and the treap
+// is built in O(N) time, as by FromSorted, with the same *UnsortedError
+// for values out of order; otherwise the values are sorted first, in
+// O(N*log(N)) time.  Because building needs the count of values, FromSeq
+// buffers the whole sequence in a slice before building.
+//
+func FromSeq(seq iter.Seq[interface{}], sorted bool) (*T, error) {
+	var nodes []*T
+	for v := range seq {
+		nodes = append(nodes, leaf(v))
+	}
+	if !sorted {
+		sort.SliceStable(nodes, func(i, j int) bool { return nodeLess(nodes[i], nodes[j]) })
+	} else if err := checkSorted(nodes); nil != err {
+		return nil, err
+	}
+	return build(nodes), nil
+}
+
+// ErrUnsorted reports values that are not in sorted order.  It is
+// returned wrapped in an *UnsortedError, so test for it with errors.Is.
+//
+var ErrUnsorted = errors.New("itreap: values not sorted")
+
+// An UnsortedError reports the first value out of sorted order, which
+// is less than the value before it, and its position.  It unwraps to
+// ErrUnsorted.
+//
+type UnsortedError struct {
+	Index int
+	Value interface{}
+}
+
+func (e *UnsortedError) Error() string {
+	return fmt.Sprintf("%v: value %v at index %d", ErrUnsorted, e.Value, e.Index)
+}
+
+func (e *UnsortedError) Unwrap() error { return ErrUnsorted }
+
+// Return an *UnsortedError for the first of nodes less than the node
+// before it, or nil if the nodes are sorted.
+//
+func checkSorted(nodes []*T) error {
+	for i := 1; i < len(nodes); i++ {
+		if nodeLess(nodes[i], nodes[i-1]) {
+			return &UnsortedError{i, nodes[i].value}
+		}
+	}
+	return nil
+}
+
+// FromSorted returns a new treap holding the values of the slice,
+// which must already be in sorted order, in O(N) time.  Each value is
+// given a random priority as by Insert, so the result is a treap of the
+// same shape as one built by inserting the values one at a time.  If a
+// value is less than the one before it, no treap is built and an
+// *UnsortedError reports its index.
+//
+func FromSorted(values []interface{}) (*T, error) {
+	nodes := make([]*T, len(values))
+	for i, v := range values {
+		nodes[i] = leaf(v)
+	}
+	if err := checkSorted(nodes); nil != err {
+		return nil, err
+	}
+	return build(nodes), nil
+}
+
+// A Builder accumulates values for a new treap.  While the values are
+// added in sorted order, Add takes amortized O(1) time and Build links
+// them in O(N) time, as for FromSorted.  After the first value added out
+// of order, the values so far are built into a treap and each later Add
+// is an Insert, in O(log(N)) time.  The zero Builder is empty and ready
+// to use.
+//
+type Builder struct {
+	nodes    []*T
+	t        *T
+	unsorted bool
+}
+
+// Add adds value to the treap being built.
+//
+func (b *Builder) Add(value interface{}) {
+	if b.unsorted {
+		b.t = b.t.Insert(value)
+		return
+	}
+	nu := leaf(value)
+	if n := len(b.nodes); 0 < n && nodeLess(nu, b.nodes[n-1]) {
+		b.t, b.nodes, b.unsorted = build(b.nodes).Insert(value), nil, true
+		return
+	}
+	b.nodes = append(b.nodes, nu)
+}
+
+// Build returns the treap of the values added.  It empties the Builder,
+// so each Build returns only the values added since the last, and the
+// Builder may be reused for a new treap.
+//
+func (b *Builder) Build() *T {
+	t := b.t
+	if !b.unsorted {
+		t = build(b.nodes)
+	}
+	*b = Builder{}
+	return t
+}
+
+// Merge returns a new treap holding the values of all the trees, in
+// O(N*log(K)) time for N values in K trees.  Like Union, Merge keeps
+// equal values from every tree.  The trees are merged in order into a
+// sorted slice of nodes, from which the result is built directly.  The
+// trees are unchanged.  An empty result keeps the config of the first
+// tree with one, such as an empty tree built by NewWithLess.
+//
+func Merge(trees ...*T) *T {
+	var h heads
+	var first *T
+	total := 0
+	for _, t := range trees {
+		if nil == first && nil != t.config() && t.cfg.kept() {
+			first = t
+		}
+		w := t.walk()
+		if n := w.next(); nil != n {
+			h = append(h, head{n, w})
+			total += t.Len()
+		}
+	}
+	heap.Init(&h)
+	nodes := make([]*T, 0, total)
+	for 0 < len(h) {
+		n := h[0].n
+		nodes = append(nodes, &T{1, n.priority, n.value, n.score, nil, nil, n.cfg, n.own, n.own})
+		if h[0].n = h[0].w.next(); nil != h[0].n {
+			heap.Fix(&h, 0)
+		} else {
+			heap.Pop(&h)
+		}
+	}
+	return first.rooted(build(nodes))
+}
+
+// A head is the next node of a walk, and heads is a heap of them with
+// the least node first, for Merge.
+//
+type head struct {
+	n *T
+	w *walker
+}
+
+type heads []head
+
+func (h heads) Len() int            { return len(h) }
+func (h heads) Less(i, j int) bool  { return nodeLess(h[i].n, h[j].n) }
+func (h heads) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *heads) Push(x interface{}) { *h = append(*h, x.(head)) }
+
+func (h *heads) Pop() interface{} {
+	n := len(*h) - 1
+	x := (*h)[n]
+	*h = (*h)[:n]
+	return x
+}
+
+// InsertAll returns a new tree like the original, but with all the
+// values inserted, as by repeated calls to Insert.  The values are
+// sorted and built into a treap in O(M*log(M)) time, which is then
+// merged into the tree by Union.  The original tree and the values
+// slice are unchanged.
+//
+func (t *T) InsertAll(values []interface{}) *T {
+	if 0 == len(values) {
+		return t
+	}
+	nodes := make([]*T, len(values))
+	for i, v := range values {
+		_, score := t.fnScore(v)
+		nodes[i] = &T{1, t.newPriority(v), v, score, nil, nil, t.config(), 1, 1}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodeLess(nodes[i], nodes[j]) })
+	return t.Union(build(nodes))
+}
+
+// Filter returns a new list of the values of the list for which keep
+// returns true, in order, in O(N) time.  The surviving values are
+// already sorted, so the result is built directly rather than by
+// insertion.  The original list is unchanged.
+//
+func (t *T) Filter(keep func(value interface{}) bool) *T {
+	var nodes []*T
+	w := t.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		if keep(n.value) {
+			nodes = append(nodes, &T{1, n.priority, n.value, n.score, nil, nil, n.cfg, n.own, n.own})
+		}
+	}
+	return t.rooted(build(nodes))
+}
+
+// Rebalance returns a new treap of the values of the list, in order,
+// with fresh random priorities, in O(N) time.  Like FromSorted, it
+// builds the result directly, and its expected height is O(log(N))
+// whatever the shape of the original, as after unlucky or hashed
+// priorities.  The original list is unchanged.
+//
+func (t *T) Rebalance() *T {
+	nodes := make([]*T, 0, t.Len())
+	w := t.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		nodes = append(nodes, &T{1, priority(), n.value, n.score, nil, nil, n.cfg, n.own, n.own})
+	}
+	return t.rooted(build(nodes))
+}
+
+// Map returns a new list of the values fn returns for each value of the
+// list, in O(N*log(N)) time.  Because fn need not preserve order, the
+// result is rebuilt by inserting each new value, ordered by the new
+// values themselves.  For a NewWithLess list, the new values are
+// ordered by the same less function.  MapMonotone is faster when fn
+// preserves order.
+//
+func (t *T) Map(fn func(value interface{}) interface{}) *T {
+	nu := t.rooted(nil)
+	w := t.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		nu = nu.Insert(fn(n.value))
+	}
+	return nu
+}
+
+// MapMonotone returns a new list of the values fn returns for each value
+// of the list, in O(N) time.  fn must preserve order, so that if a
+// precedes b then fn(a) does not follow fn(b); the new values are then
+// already sorted, and the result is built directly, with the shape of
+// the original.  Use Map when fn may reorder values.
+//
+func (t *T) MapMonotone(fn func(value interface{}) interface{}) *T {
+	var nodes []*T
+	w := t.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		v := fn(n.value)
+		_, score := t.fnScore(v)
+		nodes = append(nodes, &T{1, n.priority, v, score, nil, nil, n.cfg, n.own, n.own})
+	}
+	return t.rooted(build(nodes))
+}
+
+// Reverse returns a new treap of the values of the list under the
+// reversed order, so that its values run from greatest to least, in
+// O(N) time.  The result orders later insertions in reverse too: it has
+// the negated less function of a NewWithLess list, or for an ordinal
+// list a less function reversing the ordinal comparison.  The result
+// of a NewHashed list keeps its hash.
+//
+func (t *T) Reverse() *T {
+	cfg := &config{less: t.reverseLess()}
+	if c := t.config(); nil != c {
+		cfg.hash = c.hash
+	}
+	var nodes []*T
+	w := t.walkReverse()
+	for n := w.next(); nil != n; n = w.next() {
+		nodes = append(nodes, &T{1, n.priority, n.value, 0, nil, nil, cfg, n.own, n.own})
+	}
+	return (&T{cfg: cfg}).rooted(build(nodes))
+}
+
+// Return a less function ordering values in the reverse of the order of
+// treap t.
+//
+func (t *T) reverseLess() func(a, b interface{}) bool {
+	if c := t.config(); nil != c && nil != c.less {
+		less := c.less
+		return func(a, b interface{}) bool { return less(b, a) }
+	}
+	return func(a, b interface{}) bool {
+		less, sb := ordinal.FnScore(b)
+		_, sa := ordinal.FnScore(a)
+		return sb < sa || sb == sa && less(b, a)
+	}
+}
+
+// Return a new single-node treap holding value.
+//
+func leaf(value interface{}) *T {
+	_, score := ordinal.FnScore(value)
+	return &T{1, priority(), value, score, nil, nil, nil, 1, 1}
+}
+
+// Link the sorted, unshared, single-node treaps into one treap in O(N)
+// time, keeping the priorities they were given so the result has the
+// same shape as if the values were inserted one at a time.  The right
+// spine of the partial treap is kept on a stack, and each new node
+// adopts as its left child the spine nodes of lower priority.
+//
+func build(nodes []*T) *T {
+	var spine []*T
+	for _, nu := range nodes {
+		var last *T
+		for n := len(spine); 0 < n && spine[n-1].priority < nu.priority; n-- {
+			last = spine[n-1]
+			spine = spine[:n-1]
+		}
+		nu.left = last
+		if n := len(spine); 0 < n {
+			spine[n-1].right = nu
+		}
+		spine = append(spine, nu)
+	}
+	if 0 == len(spine) {
+		return nil
+	}
+	root := spine[0]
+	root.recount()
+	return root
+}
+
+// Recompute the counts and weights of the unshared treap t, bottom up.
+//
+func (t *T) recount() {
+	if nil == t {
+		return
+	}
+	t.left.recount()
+	t.right.recount()
+	t.count = 1 + sum(t.left, t.right)
+	t.weight = t.own + weigh(t.left, t.right)
+}
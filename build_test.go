@@ -0,0 +1,378 @@
+package itreap
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestFromSeq(s *testing.T) {
+	s.Parallel()
+	for _, sorted := range []bool{true, false} {
+		for _, n := range []int{0, 1, 2, 100} {
+			in := rand.Perm(n)
+			if sorted {
+				for i := range in {
+					in[i] = i
+				}
+			}
+			t, err := FromSeq(func(yield func(interface{}) bool) {
+				for _, v := range in {
+					if !yield(v) {
+						return
+					}
+				}
+			}, sorted)
+			if nil != err {
+				s.Fatal(err)
+			}
+			if err := t.VerifyAugmented(); nil != err {
+				s.Error(err)
+			}
+			if t.String() != itreap(n).String() {
+				s.Errorf("%v != %v", t, itreap(n))
+			}
+		}
+	}
+	if t, err := FromSeq(itreap(10).seq(), true); nil != err || t.String() != "0 1 2 3 4 5 6 7 8 9" {
+		s.Error(t, err)
+	}
+	var e *UnsortedError
+	unsorted := func(yield func(interface{}) bool) {
+		for _, v := range []interface{}{1, 3, 2} {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+	if t, err := FromSeq(unsorted, true); nil != t || !errors.As(err, &e) || e.Index != 2 || e.Value != 2 {
+		s.Error(t, err)
+	}
+	if t, err := FromSeq(unsorted, false); nil != err || t.String() != "1 2 3" {
+		s.Error(t, err)
+	}
+}
+
+func TestFromSorted(s *testing.T) {
+	s.Parallel()
+	for _, n := range []int{0, 1, 2, 3, 1000} {
+		values := make([]interface{}, n)
+		for i := range values {
+			values[i] = i / 2
+		}
+		t, err := FromSorted(values)
+		if nil != err {
+			s.Fatal(err)
+		}
+		if err := t.VerifyAugmented(); nil != err {
+			s.Error(err)
+		}
+		if t.Len() != n {
+			s.Error(t.Len(), " != ", n)
+		}
+		for i := range values {
+			if g := t.GetN(i); g != values[i] {
+				s.Error(g, " != ", values[i])
+			}
+		}
+	}
+}
+
+func BenchmarkFromSorted(b *testing.B) {
+	b.StopTimer()
+	values := make([]interface{}, b.N)
+	for i := range values {
+		values[i] = i
+	}
+	b.StartTimer()
+	FromSorted(values)
+}
+
+func TestFromSorted_unsorted(s *testing.T) {
+	s.Parallel()
+	t, err := FromSorted([]interface{}{0, 1, 2, 4, 3, 5})
+	if nil != t || !errors.Is(err, ErrUnsorted) {
+		s.Fatal(t, err)
+	}
+	if g := err.Error(); g != "itreap: values not sorted: value 3 at index 4" {
+		s.Error(g)
+	}
+	var e *UnsortedError
+	if !errors.As(err, &e) || e.Index != 4 || e.Value != 3 {
+		s.Error(e)
+	}
+	if _, err := FromSorted([]interface{}{1, 0}); nil == err || !strings.HasSuffix(err.Error(), "at index 1") {
+		s.Error(err)
+	}
+	for _, values := range [][]interface{}{nil, {7}, {7, 7}} {
+		t, err := FromSorted(values)
+		if nil != err || t.Len() != len(values) {
+			s.Error(values, t, err)
+		}
+	}
+}
+
+func BenchmarkFromSorted_Insert(b *testing.B) {
+	b.StopTimer()
+	values := make([]interface{}, b.N)
+	for i := range values {
+		values[i] = i
+	}
+	b.StartTimer()
+	t := New()
+	for _, v := range values {
+		t = t.Insert(v)
+	}
+}
+
+func TestT_Filter(s *testing.T) {
+	s.Parallel()
+	t := itreap(20)
+	even := t.Filter(func(v interface{}) bool { return 0 == v.(int)%2 })
+	if even.String() != "0 2 4 6 8 10 12 14 16 18" || even.Len() != 10 {
+		s.Error(even)
+	}
+	if err := even.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	if t.Len() != 20 || t.String() != itreap(20).String() {
+		s.Error("original modified")
+	}
+	none := t.Filter(func(interface{}) bool { return false })
+	if nil != none {
+		s.Error(none, " != nil")
+	}
+	if g := list(3, 1, 3, 2).Filter(func(v interface{}) bool { return 3 == v }); g.String() != "3 3" {
+		s.Error(g)
+	}
+}
+
+func TestT_Map(s *testing.T) {
+	s.Parallel()
+	t := list(3, 1, 4, 1, 5)
+	double := func(v interface{}) interface{} { return 2 * v.(int) }
+	negate := func(v interface{}) interface{} { return -v.(int) }
+	cases := []struct {
+		g    *T
+		want string
+	}{
+		{t.Map(double), "2 2 6 8 10"},
+		{t.MapMonotone(double), "2 2 6 8 10"},
+		{t.Map(negate), "-5 -4 -3 -1 -1"},
+	}
+	for _, c := range cases {
+		if c.g.String() != c.want {
+			s.Error(c.g, " != ", c.want)
+		}
+		if err := c.g.VerifyAugmented(); nil != err {
+			s.Error(err)
+		}
+	}
+	if g := t.MapMonotone(double); g.priority != t.priority || g.value != 2*t.value.(int) {
+		s.Error("MapMonotone changed shape")
+	}
+	if t.String() != "1 1 3 4 5" {
+		s.Error("original modified")
+	}
+	if nil != New().Map(negate) || nil != New().MapMonotone(double) {
+		s.Error("Map of empty list")
+	}
+}
+
+func TestT_InsertAll(s *testing.T) {
+	s.Parallel()
+	x, t := multiset(100, 50)
+	values := make([]interface{}, 60)
+	want := t
+	for i := range values {
+		values[i] = rand.Intn(70)
+		want = want.Insert(values[i])
+	}
+	g := t.InsertAll(values)
+	if g.String() != want.String() {
+		s.Error(g, " != ", want)
+	}
+	if err := g.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	if t.Len() != len(x) {
+		s.Error("original modified")
+	}
+	if g := New().InsertAll([]interface{}{3, 1, 2}); g.String() != "1 2 3" {
+		s.Error(g)
+	}
+	if t.InsertAll(nil) != t {
+		s.Error("InsertAll(nil) changed the tree")
+	}
+	byLen := NewWithLess(func(a, b interface{}) bool { return len(a.(string)) < len(b.(string)) })
+	if g := byLen.InsertAll([]interface{}{"ccc", "a", "bb"}); g.String() != "a bb ccc" {
+		s.Error(g)
+	}
+}
+
+// Inserting 10k values into a 100k-value tree, in bulk and one at a time.
+//
+func BenchmarkT_InsertAll(b *testing.B) {
+	b.StopTimer()
+	t := itreap(100000)
+	values := make([]interface{}, 10000)
+	for i := range values {
+		values[i] = rand.Intn(100000)
+	}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		t.InsertAll(values)
+	}
+}
+
+func BenchmarkT_InsertAll_Insert(b *testing.B) {
+	b.StopTimer()
+	t := itreap(100000)
+	values := make([]interface{}, 10000)
+	for i := range values {
+		values[i] = rand.Intn(100000)
+	}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		nu := t
+		for _, v := range values {
+			nu = nu.Insert(v)
+		}
+	}
+}
+
+func TestMerge(s *testing.T) {
+	s.Parallel()
+	var trees []*T
+	var want []int
+	for _, n := range []int{30, 0, 50, 20} {
+		x, t := multiset(n, 40)
+		trees = append(trees, t)
+		want = append(want, x...)
+	}
+	sort.Ints(want)
+	g := Merge(trees...)
+	if err := g.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	if g.Len() != len(want) {
+		s.Fatal(g.Len(), " != ", len(want))
+	}
+	for i, v := range want {
+		if g.GetN(i) != v {
+			s.Fatalf("Merge(...)[%d] == %v, want %d", i, g.GetN(i), v)
+		}
+	}
+	if trees[0].Len() != 30 || trees[2].Len() != 50 {
+		s.Error("inputs modified")
+	}
+	if nil != Merge() || nil != Merge(nil, New()) {
+		s.Error("Merge of no values")
+	}
+	greater := NewWithLess(func(a, b interface{}) bool { return a.(int) > b.(int) })
+	if g := Merge(New(), greater).Insert(1).Insert(2); g.String() != "2 1" {
+		s.Error("Merge of empty lists lost the ordering: ", g)
+	}
+}
+
+func TestT_Reverse(s *testing.T) {
+	s.Parallel()
+	_, t := multiset(100, 30)
+	r := t.Reverse()
+	want, g := t.ToSlice(), r.ToSlice()
+	for i := range want {
+		if g[len(g)-1-i] != want[i] {
+			s.Fatalf("Reverse() == %v", r)
+		}
+	}
+	if err := r.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	greatest, _ := t.Max()
+	if least, _ := r.Min(); r.Len() != 100 || r.GetN(0) != greatest || least != greatest {
+		s.Error("Reverse() == ", r)
+	}
+	r = r.Insert(-1).Insert(99)
+	if g := r.GetN(0); 99 != g {
+		s.Error(g, " != 99")
+	}
+	if g := r.GetN(-1); -1 != g {
+		s.Error(g, " != -1")
+	}
+	if g := r.Reverse().String(); g != t.Insert(-1).Insert(99).String() {
+		s.Error("Reverse().Reverse() == ", g)
+	}
+	byLen := NewWithLess(func(a, b interface{}) bool { return len(a.(string)) < len(b.(string)) })
+	if g := byLen.Insert("bb").Insert("a").Insert("ccc").Reverse(); g.String() != "ccc bb a" {
+		s.Error(g)
+	}
+	if g := New().Reverse().Insert(1).Insert(2); g.String() != "2 1" {
+		s.Error(g)
+	}
+}
+
+func TestBuilder(s *testing.T) {
+	s.Parallel()
+	var b Builder
+	for i := 0; i < 100; i++ {
+		b.Add(i / 2)
+	}
+	if b.unsorted {
+		s.Error("sorted values took the slow path")
+	}
+	t := b.Build()
+	if err := t.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	if t.Len() != 100 || t.GetN(99) != 49 {
+		s.Error(t)
+	}
+
+	// One value out of order.
+	for _, v := range []int{1, 2, 4, 3, 5, 6} {
+		b.Add(v)
+	}
+	if !b.unsorted {
+		s.Error("unsorted values took the fast path")
+	}
+	t = b.Build()
+	if err := t.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	if t.String() != "1 2 3 4 5 6" {
+		s.Error(t)
+	}
+
+	if t = b.Build(); nil != t {
+		s.Error("Build of emptied Builder == ", t)
+	}
+}
+
+func TestT_Rebalance(s *testing.T) {
+	s.Parallel()
+	const n = 1000
+	nodes := make([]*T, n)
+	for i := range nodes {
+		nodes[i] = &T{1, int32(n - i), i, float64(i), nil, nil, nil, 1, 1}
+	}
+	t := build(nodes) // a right spine n deep
+	r := t.Rebalance()
+	if err := r.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	if r.String() != t.String() {
+		s.Error("Rebalance changed the values")
+	}
+	// The expected height is about 3*ln(n), or 21; allow 4*log2(n).
+	if h := r.Height(); 40 < h {
+		s.Errorf("height %d after Rebalance of %d values", h, n)
+	}
+	if t.Height() != n {
+		s.Error("original modified")
+	}
+	if nil != New().Rebalance() {
+		s.Error("Rebalance of empty list")
+	}
+}
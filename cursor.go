@@ -0,0 +1,147 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+// A Cursor is a position in an immutable list that can step both
+// forward and backward.  It keeps the path of ancestors from the root to
+// its node, so each step takes amortized O(1) time.  A cursor may also
+// rest before the first value or after the last, where it has no value.
+// Because the list is immutable, the cursor never observes later
+// changes.
+//
+type Cursor struct {
+	root  *T
+	path  []*T
+	index int
+}
+
+// SeekIndex returns a cursor at position n of the list, in O(log(N))
+// time.  If n < 0 the cursor is before the first value, and if
+// n >= t.Len() it is after the last.
+//
+func (t *T) SeekIndex(n int) *Cursor {
+	c := &Cursor{root: t.root()}
+	c.seekIndex(n)
+	return c
+}
+
+// SeekValue returns a cursor at the first value of the list that is not
+// less than value, in O(log(N)) time.  If there is no such value, the
+// cursor is after the last value.
+//
+func (t *T) SeekValue(value interface{}) *Cursor {
+	less, s := t.fnScore(value)
+	c := &Cursor{root: t.root(), index: t.Len()}
+	depth, rank := 0, 0
+	for n := c.root; nil != n; {
+		c.path = append(c.path, n)
+		if n.precedes(value, s, less) {
+			rank += n.left.Len() + 1
+			n = n.right
+		} else {
+			depth, c.index = len(c.path), rank+n.left.Len()
+			n = n.left
+		}
+	}
+	c.path = c.path[:depth]
+	return c
+}
+
+// Move cursor c to position n, or before or after the list if n is out
+// of range.
+//
+func (c *Cursor) seekIndex(n int) {
+	c.path = c.path[:0]
+	if n < 0 {
+		c.index = -1
+		return
+	}
+	if n >= c.root.Len() {
+		c.index = c.root.Len()
+		return
+	}
+	c.index = n
+	for t := c.root; ; {
+		c.path = append(c.path, t)
+		lcount := t.left.Len()
+		switch {
+		case n < lcount:
+			t = t.left
+		case lcount < n:
+			n -= lcount + 1
+			t = t.right
+		default:
+			return
+		}
+	}
+}
+
+// Value returns the value at the cursor.  If the cursor is before the
+// first value or after the last, ok is false.
+//
+func (c *Cursor) Value() (value interface{}, ok bool) {
+	n := len(c.path)
+	if 0 == n {
+		return nil, false
+	}
+	return c.path[n-1].value, true
+}
+
+// Index returns the position of the cursor in the list: -1 before the
+// first value, and the length of the list after the last.
+//
+func (c *Cursor) Index() int { return c.index }
+
+// Next moves the cursor to the next value of the list and returns it.
+// Past the last value, the cursor rests after the list and ok is false.
+// From before the first value, Next moves to the first.
+//
+func (c *Cursor) Next() (value interface{}, ok bool) {
+	n := len(c.path)
+	switch {
+	case 0 == n && c.index < 0:
+		c.seekIndex(0)
+	case 0 == n:
+		return nil, false
+	default:
+		c.index++
+		if t := c.path[n-1].right; nil != t {
+			for ; nil != t; t = t.left {
+				c.path = append(c.path, t)
+			}
+		} else {
+			// Climb to the nearest ancestor reached from its left.
+			for n--; 0 < n && c.path[n-1].right == c.path[n]; n-- {
+			}
+			c.path = c.path[:n]
+		}
+	}
+	return c.Value()
+}
+
+// Prev moves the cursor to the previous value of the list and returns
+// it.  Before the first value, the cursor rests before the list and ok
+// is false.  From after the last value, Prev moves to the last.
+//
+func (c *Cursor) Prev() (value interface{}, ok bool) {
+	n := len(c.path)
+	switch {
+	case 0 == n && c.index >= c.root.Len():
+		c.seekIndex(c.root.Len() - 1)
+	case 0 == n:
+		return nil, false
+	default:
+		c.index--
+		if t := c.path[n-1].left; nil != t {
+			for ; nil != t; t = t.right {
+				c.path = append(c.path, t)
+			}
+		} else {
+			// Climb to the nearest ancestor reached from its right.
+			for n--; 0 < n && c.path[n-1].left == c.path[n]; n-- {
+			}
+			c.path = c.path[:n]
+		}
+	}
+	return c.Value()
+}
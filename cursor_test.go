@@ -0,0 +1,83 @@
+package itreap
+
+import "testing"
+
+func TestT_SeekIndex(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	c := t.SeekIndex(50)
+	if v, ok := c.Value(); !ok || 50 != v || 50 != c.Index() {
+		s.Fatal("SeekIndex(50) at ", v, ", ", c.Index())
+	}
+	for i := 0; i < 20; i++ {
+		if v, ok := c.Next(); !ok || 51 != v || 51 != c.Index() {
+			s.Fatal("Next == ", v, ", ", c.Index())
+		}
+		if v, ok := c.Prev(); !ok || 50 != v || 50 != c.Index() {
+			s.Fatal("Prev == ", v, ", ", c.Index())
+		}
+	}
+	// Walk off each end and back.
+	for i := 51; i < 100; i++ {
+		if v, _ := c.Next(); i != v || i != c.Index() {
+			s.Fatal(v, " != ", i)
+		}
+	}
+	if v, ok := c.Next(); ok || 100 != c.Index() {
+		s.Error("Next past end == ", v, ", ", c.Index())
+	}
+	if _, ok := c.Next(); ok || 100 != c.Index() {
+		s.Error("Next past end moved the cursor")
+	}
+	if v, ok := c.Prev(); !ok || 99 != v {
+		s.Error("Prev from end == ", v)
+	}
+	for i := 98; i >= 0; i-- {
+		if v, _ := c.Prev(); i != v || i != c.Index() {
+			s.Fatal(v, " != ", i)
+		}
+	}
+	if v, ok := c.Prev(); ok || -1 != c.Index() {
+		s.Error("Prev past start == ", v, ", ", c.Index())
+	}
+	if v, ok := c.Next(); !ok || 0 != v {
+		s.Error("Next from start == ", v)
+	}
+	if _, ok := t.SeekIndex(100).Value(); ok {
+		s.Error("SeekIndex(100) has a value")
+	}
+	if _, ok := New().SeekIndex(0).Next(); ok {
+		s.Error("Next in empty list")
+	}
+}
+
+func TestT_SeekValue(s *testing.T) {
+	s.Parallel()
+	t := list(10, 20, 20, 30)
+	cases := []struct {
+		v, at, index int
+		ok           bool
+	}{
+		{5, 10, 0, true},
+		{10, 10, 0, true},
+		{15, 20, 1, true},
+		{20, 20, 1, true},
+		{30, 30, 3, true},
+		{35, 0, 4, false},
+	}
+	for _, c := range cases {
+		cur := t.SeekValue(c.v)
+		v, ok := cur.Value()
+		if ok != c.ok || ok && v != c.at || cur.Index() != c.index {
+			s.Errorf("SeekValue(%d) at %v, %d", c.v, v, cur.Index())
+		}
+	}
+	c := t.SeekValue(20)
+	c.Next()
+	if v, _ := c.Prev(); 20 != v || 1 != c.Index() {
+		s.Error("Prev == ", v, ", ", c.Index())
+	}
+	if v, _ := c.Prev(); 10 != v || 0 != c.Index() {
+		s.Error("Prev == ", v, ", ", c.Index())
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+)
+
+// MarshalJSON encodes the list as a JSON array of its values in order.
+//
+func (t *T) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('[')
+	w := t.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		if 1 != b.Len() {
+			b.WriteByte(',')
+		}
+		data, err := json.Marshal(n.value)
+		if nil != err {
+			return nil, err
+		}
+		b.Write(data)
+	}
+	b.WriteByte(']')
+	return b.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON array of sorted values, as written by
+// MarshalJSON, into a new treap in O(N) time.  For each element, elem
+// must return a pointer to a fresh value to decode into, such as
+// new(int), and the value pointed to is stored.  To store pointers,
+// elem should return a pointer to a pointer.  The treap is ordered by
+// the ordinal package, so a list built by NewWithLess is not restored
+// with its ordering, and values out of order yield an error wrapping
+// ErrUnsorted.
+//
+func UnmarshalJSON(data []byte, elem func() interface{}) (*T, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); nil != err {
+		return nil, err
+	}
+	values := make([]interface{}, len(raw))
+	for i, r := range raw {
+		p := elem()
+		if err := json.Unmarshal(r, p); nil != err {
+			return nil, err
+		}
+		values[i] = reflect.ValueOf(p).Elem().Interface()
+	}
+	return FromSorted(values)
+}
+
+// GobEncode encodes the values of the list in order, implementing
+// gob.GobEncoder.  The values are encoded as interfaces, so the caller
+// must gob.Register each concrete type stored in the list.
+//
+func (t *T) GobEncode() ([]byte, error) {
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(t.ToSlice()); nil != err {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// GobDecode decodes values written by GobEncode into a treap built in
+// O(N) time, implementing gob.GobDecoder.  Unlike every other method, it
+// overwrites its receiver, which must be a newly allocated T, as
+// supplied by the gob package when decoding a *T.  The treap is ordered
+// by the ordinal package, and values out of order yield an error
+// wrapping ErrUnsorted.
+//
+func (t *T) GobDecode(data []byte) error {
+	var values []interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&values); nil != err {
+		return err
+	}
+	nu, err := FromSorted(values)
+	if nil != err {
+		return err
+	}
+	if nil != nu {
+		*t = *nu
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package itreap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"testing"
+)
+
+func init() {
+	gob.Register(point{})
+}
+
+type point struct{ X, Y int }
+
+func (a point) Less(b interface{}) bool {
+	p := b.(point)
+	return a.X < p.X || a.X == p.X && a.Y < p.Y
+}
+
+func TestT_MarshalJSON(s *testing.T) {
+	s.Parallel()
+	t := itreap(10)
+	data, err := json.Marshal(t)
+	if nil != err {
+		s.Fatal(err)
+	}
+	if string(data) != "[0,1,2,3,4,5,6,7,8,9]" {
+		s.Error(string(data))
+	}
+	if data, _ := New().MarshalJSON(); string(data) != "[]" {
+		s.Error(string(data))
+	}
+}
+
+func TestUnmarshalJSON(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	data, _ := json.Marshal(t)
+	u, err := UnmarshalJSON(data, func() interface{} { return new(int) })
+	if nil != err {
+		s.Fatal(err)
+	}
+	if err := u.Verify(); nil != err {
+		s.Error(err)
+	}
+	if !u.Equal(t) {
+		s.Error(u, " != ", t)
+	}
+
+	p := list(point{2, 1}, point{1, 5}, point{1, 2})
+	data, _ = json.Marshal(p)
+	q, err := UnmarshalJSON(data, func() interface{} { return new(point) })
+	if nil != err {
+		s.Fatal(err)
+	}
+	if q.String() != "{1 2} {1 5} {2 1}" || !q.Equal(p) {
+		s.Error(q)
+	}
+
+	if _, err := UnmarshalJSON([]byte(`[1,"x"]`), func() interface{} { return new(int) }); nil == err {
+		s.Error("decoded a string as an int")
+	}
+	if e, err := UnmarshalJSON([]byte(`[]`), func() interface{} { return new(int) }); nil != err || 0 != e.Len() {
+		s.Error(e, err)
+	}
+}
+
+func TestT_Gob(s *testing.T) {
+	s.Parallel()
+	type snapshot struct {
+		Name   string
+		Points *T
+	}
+	for _, p := range []*T{list(point{2, 1}, point{1, 5}, point{1, 2}), New()} {
+		var b bytes.Buffer
+		if err := gob.NewEncoder(&b).Encode(snapshot{"points", p}); nil != err {
+			s.Fatal(err)
+		}
+		var q snapshot
+		if err := gob.NewDecoder(&b).Decode(&q); nil != err {
+			s.Fatal(err)
+		}
+		if q.Name != "points" || !q.Points.Equal(p) {
+			s.Error(q.Points, " != ", p)
+		}
+		if err := q.Points.Verify(); nil != err {
+			s.Error(err)
+		}
+		if q.Points.Len() != p.Len() || q.Points.String() != p.String() {
+			s.Error(q.Points, " != ", p)
+		}
+	}
+}
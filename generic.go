@@ -0,0 +1,229 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Type Tree is an immutable ordered list of values of type K, ordered by
+// a less function supplied at construction.  Values are stored without
+// boxing and compared without the ordinal package.
+//
+type Tree[K any] struct {
+	less func(a, b K) bool
+	root *node[K]
+}
+
+type node[K any] struct {
+	count       int
+	priority    int32
+	value       K
+	left, right *node[K]
+}
+
+// NewTree returns an empty immutable list ordered by less, which must
+// return true iff a is less than b.
+//
+func NewTree[K any](less func(a, b K) bool) *Tree[K] { return &Tree[K]{less, nil} }
+
+func (t *Tree[K]) with(root *node[K]) *Tree[K] { return &Tree[K]{t.less, root} }
+
+// Len returns the number of values in the list.
+//
+func (t *Tree[K]) Len() int { return t.root.len() }
+
+func (n *node[K]) len() int {
+	if nil == n {
+		return 0
+	}
+	return n.count
+}
+
+// Contains returns true iff the tree contains the specified value, in O(log(N)) time.
+//
+func (t *Tree[K]) Contains(value K) bool {
+	for n := t.root; nil != n; {
+		switch {
+		case t.less(value, n.value):
+			n = n.left
+		case t.less(n.value, value):
+			n = n.right
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Insert returns a new tree like the original, but with the value inserted, in O(log(N)) time.
+//
+func (t *Tree[K]) Insert(value K) *Tree[K] {
+	nu := &node[K]{1, priority(), value, nil, nil}
+	return t.with(t.root.insert(nu, t.less))
+}
+
+func (n *node[K]) insert(nu *node[K], less func(a, b K) bool) *node[K] {
+	if nil == n {
+		return nu
+	}
+	if less(n.value, nu.value) {
+		right := n.right.insert(nu, less)
+		if right.priority > n.priority {
+			// Rotate left, replacing n.right with right.
+			return &node[K]{
+				n.count + 1, right.priority, right.value,
+				&node[K]{1 + n.left.len() + right.left.len(), n.priority, n.value, n.left, right.left},
+				right.right}
+		}
+		return &node[K]{n.count + 1, n.priority, n.value, n.left, right}
+	}
+	left := n.left.insert(nu, less)
+	if left.priority > n.priority {
+		// Rotate right, replacing n.left with left.
+		return &node[K]{
+			n.count + 1, left.priority, left.value, left.left,
+			&node[K]{1 + left.right.len() + n.right.len(), n.priority, n.value, left.right, n.right}}
+	}
+	return &node[K]{n.count + 1, n.priority, n.value, left, n.right}
+}
+
+// Remove returns a new tree like the original, but with the value removed, in O(log(N)) time.
+// If there is no matching value to remove, the original tree is returned.
+// If there are multiple matching values, only one is removed.
+//
+func (t *Tree[K]) Remove(value K) *Tree[K] {
+	root, ok := t.root.remove(value, t.less)
+	if !ok {
+		return t
+	}
+	return t.with(root)
+}
+
+func (n *node[K]) remove(value K, less func(a, b K) bool) (*node[K], bool) {
+	if nil == n {
+		return nil, false
+	}
+	if less(n.value, value) {
+		right, ok := n.right.remove(value, less)
+		return &node[K]{n.count - 1, n.priority, n.value, n.left, right}, ok
+	}
+	if !less(value, n.value) {
+		return n.removeNode(), true
+	}
+	left, ok := n.left.remove(value, less)
+	return &node[K]{n.count - 1, n.priority, n.value, left, n.right}, ok
+}
+
+func (n *node[K]) removeNode() *node[K] {
+	left, right := n.left, n.right
+	if nil == left {
+		return right
+	}
+	if nil == right {
+		return left
+	}
+	// Find and remove the successor node.
+	s, right := right.removeLeftmost()
+	// Replace the top (removed) node with the successor, and restore priority.
+	return (&node[K]{n.count - 1, s.priority, s.value, left, right}).prioritize()
+}
+
+func (n *node[K]) removeLeftmost() (left *node[K], after *node[K]) {
+	if nil == n.left {
+		return n, n.right
+	}
+	s, left := n.left.removeLeftmost()
+	return s, &node[K]{n.count - 1, n.priority, n.value, left, n.right}
+}
+
+// Move misprioritized node n down to its appropriate heap level.
+// n.left and n.right are valid treaps.
+//
+func (n *node[K]) prioritize() *node[K] {
+	left, right := n.left, n.right
+	if (nil == left || left.priority <= n.priority) && (nil == right || right.priority <= n.priority) {
+		return n
+	}
+	if nil == right || nil != left && left.priority > right.priority {
+		return &node[K]{
+			n.count, left.priority, left.value, left.left,
+			(&node[K]{1 + left.right.len() + right.len(), n.priority, n.value, left.right, right}).prioritize()}
+	}
+	return &node[K]{
+		n.count, right.priority, right.value,
+		(&node[K]{1 + left.len() + right.left.len(), n.priority, n.value, left, right.left}).prioritize(),
+		right.right}
+}
+
+// RemoveN removes the nth element from the list, returning the modified
+// list and removed value.  If n is out of range, the original list is
+// returned with ok false.
+//
+func (t *Tree[K]) RemoveN(n int) (nu *Tree[K], value K, ok bool) {
+	if n < 0 || t.Len() <= n {
+		return t, value, false
+	}
+	root, value := t.root.removeN(n)
+	return t.with(root), value, true
+}
+
+func (n *node[K]) removeN(i int) (*node[K], K) {
+	lcount := n.left.len()
+	if i < lcount {
+		left, value := n.left.removeN(i)
+		return &node[K]{n.count - 1, n.priority, n.value, left, n.right}, value
+	}
+	if i > lcount {
+		right, value := n.right.removeN(i - lcount - 1)
+		return &node[K]{n.count - 1, n.priority, n.value, n.left, right}, value
+	}
+	return n.removeNode(), n.value
+}
+
+// GetN returns the value at position n in the list.  If n is not in
+// the interval [0,t.Len()), ok is false.
+//
+func (t *Tree[K]) GetN(n int) (value K, ok bool) {
+	if n < 0 || t.Len() <= n {
+		return value, false
+	}
+	for a := t.root; ; {
+		lcount := a.left.len()
+		switch {
+		case n < lcount:
+			a = a.left
+		case lcount < n:
+			n -= lcount + 1
+			a = a.right
+		default:
+			return a.value, true
+		}
+	}
+}
+
+// Return a string representation of the immutable list.
+//
+func (t *Tree[K]) String() string {
+	var b strings.Builder
+	first := true
+	t.root.format(&b, &first)
+	return b.String()
+}
+
+// Write the values of subtree n to b, separated by spaces, where first
+// is true until a value has been written.
+//
+func (n *node[K]) format(b *strings.Builder, first *bool) {
+	if nil == n {
+		return
+	}
+	n.left.format(b, first)
+	if !*first {
+		b.WriteByte(' ')
+	}
+	*first = false
+	fmt.Fprint(b, n.value)
+	n.right.format(b, first)
+}
@@ -0,0 +1,173 @@
+package itreap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func intLess(a, b int) bool { return a < b }
+
+func tree(n int) *Tree[int] {
+	rv := NewTree(intLess)
+	for _, v := range rand.Perm(n) {
+		rv = rv.Insert(v)
+	}
+	return rv
+}
+
+func (n *node[K]) verifyCounts(t *testing.T) {
+	if nil == n {
+		return
+	}
+	if n.count != 1+n.left.len()+n.right.len() {
+		t.Errorf("%v != 1 + %v + %v", n.count, n.left.len(), n.right.len())
+	}
+	for _, c := range []*node[K]{n.left, n.right} {
+		if nil != c && c.priority > n.priority {
+			t.Errorf("priority %v above parent %v", c.priority, n.priority)
+		}
+	}
+	n.left.verifyCounts(t)
+	n.right.verifyCounts(t)
+}
+
+func TestTree_Insert(t *testing.T) {
+	t.Parallel()
+	a := rand.Perm(6)
+	i := NewTree(intLess)
+	for _, v := range a {
+		before := i.String()
+		nu := i.Insert(v)
+		after := i.String()
+		if before != after {
+			t.Error(before + " != " + after)
+		}
+		next := nu.String()
+		if before == next {
+			t.Error(before + " == " + next)
+		}
+		i = nu
+		i.root.verifyCounts(t)
+	}
+	s := i.String()
+	x := "0 1 2 3 4 5"
+	if s != x {
+		t.Error(s + " != " + x)
+	}
+}
+
+func TestTree_Remove(t *testing.T) {
+	t.Parallel()
+
+	// Remove entries one at a time, confirming that an entry is removed each time.
+	// If a wrong entry is removed, a later remove will fail.
+
+	i := tree(100)
+	rm := rand.Perm(100)
+	for _, v := range rm {
+		before := i.String()
+		nu := i.Remove(v)
+		after := i.String()
+		if before != after {
+			t.Error(before + " != " + after)
+		}
+		next := nu.String()
+		if before == next {
+			t.Error(before + " == " + next)
+		}
+		i = nu
+		i.root.verifyCounts(t)
+	}
+	final := i.String()
+	if final != "" {
+		t.Error(final + " != ")
+	}
+
+	// Remove first/middle/last entries and check that the right one was removed.
+
+	i = tree(11).Remove(0).Remove(5).Remove(10)
+	s := i.String()
+	x := "1 2 3 4 6 7 8 9"
+	if s != x {
+		t.Error(s + " != " + x)
+	}
+	if i.Remove(5) != i {
+		t.Error("Remove of absent value copied the tree")
+	}
+}
+
+func TestTree_ContainsGetN(t *testing.T) {
+	t.Parallel()
+	i := tree(100)
+	for v := 0; v < 100; v++ {
+		if !i.Contains(v) {
+			t.Error("!Contains(", v, ")")
+		}
+		if g, ok := i.GetN(v); !ok || g != v {
+			t.Error(g, " != ", v)
+		}
+	}
+	if i.Contains(100) || i.Contains(-1) {
+		t.Error("Contains absent value")
+	}
+	if _, ok := i.GetN(100); ok {
+		t.Error("GetN out of range")
+	}
+}
+
+func TestTree_RemoveN(t *testing.T) {
+	t.Parallel()
+	i := tree(100)
+	for n := 100; n > 0; n-- {
+		if i.Len() != n {
+			t.Error(i.Len(), " != ", n)
+		}
+		k := rand.Intn(n)
+		x, _ := i.GetN(k)
+		nu, v, ok := i.RemoveN(k)
+		if !ok || v != x {
+			t.Error(v, " != ", x)
+		}
+		i = nu
+		i.root.verifyCounts(t)
+	}
+	if _, _, ok := i.RemoveN(0); ok {
+		t.Error("RemoveN of empty list")
+	}
+}
+
+func TestTree_Less(t *testing.T) {
+	t.Parallel()
+	type pair struct{ k, v int }
+	i := NewTree(func(a, b pair) bool { return a.k > b.k })
+	for _, k := range rand.Perm(5) {
+		i = i.Insert(pair{k, -k})
+	}
+	if s := i.String(); s != "{4 -4} {3 -3} {2 -2} {1 -1} {0 0}" {
+		t.Error(s)
+	}
+}
+
+func TestTree_String(t *testing.T) {
+	t.Parallel()
+	i := NewTree(func(a, b string) bool { return a < b })
+	for _, v := range []string{"a", "", ""} {
+		i = i.Insert(v)
+	}
+	if s := i.String(); s != "  a" {
+		t.Errorf("%q", s)
+	}
+	if s := NewTree(intLess).String(); s != "" {
+		t.Errorf("%q", s)
+	}
+}
+
+func BenchmarkTree_Insert(b *testing.B) {
+	b.StopTimer()
+	in := rand.Perm(b.N)
+	t := NewTree(intLess)
+	b.StartTimer()
+	for _, v := range in {
+		t = t.Insert(v)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+// Type IntTree is an immutable ordered list of ints.  Values are stored
+// without boxing and compared directly with <, without the ordinal
+// package or a less function.  IntTree shares its nodes and the
+// restructuring of removal with Tree, but searches with its own code.
+//
+type IntTree struct {
+	root *node[int]
+}
+
+// NewIntTree returns an empty immutable list of ints.
+//
+func NewIntTree() *IntTree { return &IntTree{} }
+
+func lessInt(a, b int) bool { return a < b }
+
+// Return treap n with node nu inserted, as node.insert with lessInt, but
+// comparing directly.
+//
+func insertInt(n, nu *node[int]) *node[int] {
+	if nil == n {
+		return nu
+	}
+	if n.value < nu.value {
+		right := insertInt(n.right, nu)
+		if right.priority > n.priority {
+			// Rotate left, replacing n.right with right.
+			return &node[int]{
+				n.count + 1, right.priority, right.value,
+				&node[int]{1 + n.left.len() + right.left.len(), n.priority, n.value, n.left, right.left},
+				right.right}
+		}
+		return &node[int]{n.count + 1, n.priority, n.value, n.left, right}
+	}
+	left := insertInt(n.left, nu)
+	if left.priority > n.priority {
+		// Rotate right, replacing n.left with left.
+		return &node[int]{
+			n.count + 1, left.priority, left.value, left.left,
+			&node[int]{1 + left.right.len() + n.right.len(), n.priority, n.value, left.right, n.right}}
+	}
+	return &node[int]{n.count + 1, n.priority, n.value, left, n.right}
+}
+
+// Return treap n with one node matching value removed, as node.remove
+// with lessInt, but comparing directly.
+//
+func removeInt(n *node[int], value int) (*node[int], bool) {
+	if nil == n {
+		return nil, false
+	}
+	if n.value < value {
+		right, ok := removeInt(n.right, value)
+		return &node[int]{n.count - 1, n.priority, n.value, n.left, right}, ok
+	}
+	if !(value < n.value) {
+		return n.removeNode(), true
+	}
+	left, ok := removeInt(n.left, value)
+	return &node[int]{n.count - 1, n.priority, n.value, left, n.right}, ok
+}
+
+// Len returns the number of values in the list.
+//
+func (t *IntTree) Len() int { return t.root.len() }
+
+// Contains returns true iff the tree contains the specified value, in O(log(N)) time.
+//
+func (t *IntTree) Contains(value int) bool {
+	for n := t.root; nil != n; {
+		switch {
+		case value < n.value:
+			n = n.left
+		case n.value < value:
+			n = n.right
+		default:
+			return true
+		}
+	}
+	return false
+}
+
+// Insert returns a new tree like the original, but with the value inserted, in O(log(N)) time.
+//
+func (t *IntTree) Insert(value int) *IntTree {
+	nu := &node[int]{1, priority(), value, nil, nil}
+	return &IntTree{insertInt(t.root, nu)}
+}
+
+// Remove returns a new tree like the original, but with the value removed, in O(log(N)) time.
+// If there is no matching value to remove, the original tree is returned.
+// If there are multiple matching values, only one is removed.
+//
+func (t *IntTree) Remove(value int) *IntTree {
+	root, ok := removeInt(t.root, value)
+	if !ok {
+		return t
+	}
+	return &IntTree{root}
+}
+
+// RemoveN removes the nth element from the list, returning the modified
+// list and removed value.  If n is out of range, the original list is
+// returned with ok false.
+//
+func (t *IntTree) RemoveN(n int) (nu *IntTree, value int, ok bool) {
+	if n < 0 || t.Len() <= n {
+		return t, 0, false
+	}
+	root, value := t.root.removeN(n)
+	return &IntTree{root}, value, true
+}
+
+// GetN returns the value at position n in the list.  If n is not in
+// the interval [0,t.Len()), ok is false.
+//
+func (t *IntTree) GetN(n int) (value int, ok bool) {
+	return (&Tree[int]{lessInt, t.root}).GetN(n)
+}
+
+// Return a string representation of the immutable list.
+//
+func (t *IntTree) String() string {
+	return (&Tree[int]{lessInt, t.root}).String()
+}
@@ -0,0 +1,72 @@
+package itreap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestIntTree(t *testing.T) {
+	t.Parallel()
+	i := NewIntTree()
+	for _, v := range rand.Perm(100) {
+		i = i.Insert(v)
+	}
+	i.root.verifyCounts(t)
+	if i.Len() != 100 || i.String() != tree(100).String() {
+		t.Error(i)
+	}
+	for v := 0; v < 100; v++ {
+		if !i.Contains(v) {
+			t.Error("!Contains(", v, ")")
+		}
+		if g, ok := i.GetN(v); !ok || g != v {
+			t.Error(g, " != ", v)
+		}
+	}
+	if i.Contains(100) || i.Contains(-1) {
+		t.Error("Contains absent value")
+	}
+	if i.Remove(100) != i {
+		t.Error("Remove of absent value copied the tree")
+	}
+	nu, v, ok := i.RemoveN(10)
+	if !ok || v != 10 || nu.Contains(10) || nu.Len() != 99 {
+		t.Error("RemoveN(10) == ", v, ", ", ok)
+	}
+	for _, v := range rand.Perm(100) {
+		i = i.Remove(v)
+		i.root.verifyCounts(t)
+	}
+	if i.Len() != 0 {
+		t.Error(i)
+	}
+	if _, _, ok := i.RemoveN(0); ok {
+		t.Error("RemoveN of empty list")
+	}
+}
+
+// Compare with BenchmarkT_Insert, which boxes each int, and with
+// BenchmarkTree_Insert, which compares through a less function.
+//
+func BenchmarkIntTree_Insert(b *testing.B) {
+	b.StopTimer()
+	in := rand.Perm(b.N)
+	t := NewIntTree()
+	b.StartTimer()
+	for _, v := range in {
+		t = t.Insert(v)
+	}
+}
+
+func BenchmarkIntTree_Contains(b *testing.B) {
+	b.StopTimer()
+	t := NewIntTree()
+	for _, v := range rand.Perm(b.N) {
+		t = t.Insert(v)
+	}
+	p := rand.Perm(b.N)
+	b.StartTimer()
+	for _, v := range p {
+		t.Contains(v)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+// An Iterator yields the values of an immutable list in order.  Because
+// the list is immutable, the iterator never observes later changes.
+//
+type Iterator struct {
+	w *walker
+}
+
+// Iter returns an iterator over the values of the list, from least to
+// greatest.  Each step takes amortized O(1) time.
+//
+func (t *T) Iter() *Iterator { return &Iterator{t.walk()} }
+
+// ReverseIter returns an iterator over the values of the list, from
+// greatest to least.  Each step takes amortized O(1) time.
+//
+func (t *T) ReverseIter() *Iterator { return &Iterator{t.walkReverse()} }
+
+// ScanFrom returns an iterator over the values of the list from
+// position start to the end, in order.  Positioning takes O(log(N))
+// time and each step amortized O(1) time, so scanning k values costs
+// O(log(N)+k), rather than the O(k*log(N)) of calling GetN for each.
+// As for GetN, a negative start counts back from the end of the list.
+// A start before the first value scans the whole list, and one past
+// the last yields no values.
+//
+func (t *T) ScanFrom(start int) *Iterator {
+	if start < 0 {
+		start += t.Len()
+	}
+	w := &walker{}
+	if start <= 0 {
+		w.push(t.root())
+		return &Iterator{w}
+	}
+	// Stack each node whose left subtree holds the start, since it
+	// follows the start, and stop at the node at the start.
+	for n := t.root(); nil != n; {
+		lcount := n.left.Len()
+		if lcount < start {
+			start -= lcount + 1
+			n = n.right
+			continue
+		}
+		w.stack = append(w.stack, n)
+		if lcount == start {
+			break
+		}
+		n = n.left
+	}
+	return &Iterator{w}
+}
+
+// Next returns the next value of the list.  Once the values are
+// exhausted, ok is false.
+//
+func (i *Iterator) Next() (value interface{}, ok bool) {
+	n := i.w.next()
+	if nil == n {
+		return nil, false
+	}
+	return n.value, true
+}
+
+// ForEach calls fn with each value of the list in order, stopping early
+// if fn returns false.
+//
+func (t *T) ForEach(fn func(value interface{}) bool) {
+	w := t.walk()
+	for n := w.next(); nil != n && fn(n.value); n = w.next() {
+	}
+}
+
+// ForEachDistinct calls fn in order with each distinct value of the
+// list and the number of copies of it stored, stopping early if fn
+// returns false.  Of each run of equal values, fn is given the first.
+// Each run is skipped by rank rather than walked, so the cost is
+// O(D*log(N)) for D distinct values.
+//
+func (t *T) ForEachDistinct(fn func(value interface{}, count int) bool) {
+	root := t.root()
+	for i, n := 0, root.Len(); i < n; {
+		v := t.nodeN(i).value
+		less, s := t.fnScore(v)
+		j := root.upper(v, s, less)
+		if !fn(v, j-i) {
+			return
+		}
+		i = j
+	}
+}
+
+// ForEachRange calls fn in order with each value v of the list with
+// lo <= v < hi, stopping early if fn returns false.  Subtrees outside
+// the range are skipped, so the cost is O(log(N)) plus the number of
+// values visited.
+//
+func (t *T) ForEachRange(lo, hi interface{}, fn func(value interface{}) bool) {
+	r := t.newInterval(lo, hi)
+	if !r.empty() {
+		t.root().forEachRange(r, fn)
+	}
+}
+
+// Call fn with each value of treap t in interval r, returning false if
+// fn stopped the traversal.
+//
+func (t *T) forEachRange(r *interval, fn func(value interface{}) bool) bool {
+	if nil == t {
+		return true
+	}
+	if r.below(t) {
+		return t.right.forEachRange(r, fn)
+	}
+	if r.above(t) {
+		return t.left.forEachRange(r, fn)
+	}
+	return t.left.forEachRange(r, fn) && fn(t.value) && t.right.forEachRange(r, fn)
+}
+
+// A walker visits the nodes of a treap in order, or in reverse order,
+// using an explicit stack of pending ancestors.
+//
+type walker struct {
+	stack   []*T
+	reverse bool
+}
+
+func (t *T) walk() *walker {
+	w := &walker{}
+	w.push(t.root())
+	return w
+}
+
+func (t *T) walkReverse() *walker {
+	w := &walker{reverse: true}
+	w.push(t.root())
+	return w
+}
+
+// Push t and the chain of its children nearest the start of the walk.
+//
+func (w *walker) push(t *T) {
+	for nil != t {
+		w.stack = append(w.stack, t)
+		if w.reverse {
+			t = t.right
+		} else {
+			t = t.left
+		}
+	}
+}
+
+// Return the next node of the walk, or nil when the walk is complete.
+//
+func (w *walker) next() *T {
+	n := len(w.stack)
+	if 0 == n {
+		return nil
+	}
+	t := w.stack[n-1]
+	w.stack = w.stack[:n-1]
+	if w.reverse {
+		w.push(t.left)
+	} else {
+		w.push(t.right)
+	}
+	return t
+}
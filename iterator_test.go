@@ -0,0 +1,174 @@
+package itreap
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestT_Iter(s *testing.T) {
+	s.Parallel()
+	if _, ok := New().Iter().Next(); ok {
+		s.Error("Next of empty list")
+	}
+	t := itreap(100)
+	i := t.Iter()
+	for x := 0; x < 100; x++ {
+		v, ok := i.Next()
+		if !ok || v != x {
+			s.Error(v, " != ", x)
+		}
+	}
+	if _, ok := i.Next(); ok {
+		s.Error("Next past end")
+	}
+}
+
+func BenchmarkT_Iter(b *testing.B) {
+	b.StopTimer()
+	t := itreap(b.N)
+	b.StartTimer()
+	i := t.Iter()
+	for _, ok := i.Next(); ok; _, ok = i.Next() {
+	}
+}
+
+func BenchmarkT_GetN_all(b *testing.B) {
+	b.StopTimer()
+	t := itreap(b.N)
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		t.GetN(i)
+	}
+}
+
+func TestT_ScanFrom(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	for _, start := range []int{-200, -100, -1, 0, 1, 37, 99, 100, 200} {
+		x := start
+		switch {
+		case x < -100:
+			x = 0
+		case x < 0:
+			x += 100
+		}
+		i := t.ScanFrom(start)
+		for v, ok := i.Next(); ok; v, ok = i.Next() {
+			if v != x {
+				s.Fatal(start, ": ", v, " != ", x)
+			}
+			x++
+		}
+		if x < 100 {
+			s.Error(start, ": stopped at ", x)
+		}
+	}
+	if _, ok := New().ScanFrom(0).Next(); ok {
+		s.Error("Next of empty list")
+	}
+}
+
+// Compare with BenchmarkT_GetN_all.
+//
+func BenchmarkT_ScanFrom(b *testing.B) {
+	b.StopTimer()
+	t := itreap(b.N)
+	b.StartTimer()
+	i := t.ScanFrom(0)
+	for _, ok := i.Next(); ok; _, ok = i.Next() {
+	}
+}
+
+func TestT_ForEach(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	calls := 0
+	t.ForEach(func(v interface{}) bool {
+		if v != calls {
+			s.Error(v, " != ", calls)
+		}
+		calls++
+		return calls < 3
+	})
+	if calls != 3 {
+		s.Error(calls, " != 3")
+	}
+	calls = 0
+	t.ForEach(func(interface{}) bool { calls++; return true })
+	if calls != 100 {
+		s.Error(calls, " != 100")
+	}
+	New().ForEach(func(interface{}) bool { s.Error("called on empty list"); return true })
+}
+
+func TestT_ForEachRange(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	var got []interface{}
+	t.ForEachRange(10, 20, func(v interface{}) bool { got = append(got, v); return true })
+	if len(got) != 10 {
+		s.Error(got)
+	}
+	for i, v := range got {
+		if v != 10+i {
+			s.Error(v, " != ", 10+i)
+		}
+	}
+	calls := 0
+	t.ForEachRange(50, 200, func(interface{}) bool { calls++; return calls < 3 })
+	if calls != 3 {
+		s.Error(calls, " != 3")
+	}
+	t.ForEachRange(20, 10, func(interface{}) bool { s.Error("called on empty range"); return true })
+}
+
+func TestT_ForEachDistinct(s *testing.T) {
+	s.Parallel()
+	t := list(5, 1, 3, 3, 1, 3, 7, 5, 3)
+	var values, counts []int
+	t.ForEachDistinct(func(v interface{}, count int) bool {
+		values, counts = append(values, v.(int)), append(counts, count)
+		return true
+	})
+	if fmt.Sprint(values, counts) != "[1 3 5 7] [2 4 2 1]" {
+		s.Error(values, counts)
+	}
+	for i := 0; i < 20; i++ {
+		x, t := multiset(rand.Intn(100), 20)
+		j := 0
+		t.ForEachDistinct(func(v interface{}, count int) bool {
+			if j >= len(x) || v != x[j] || (0 < j && x[j-1] == x[j]) {
+				s.Fatal(v, " at ", j, " of ", x)
+			}
+			j += count
+			return true
+		})
+		if j != len(x) {
+			s.Error(j, " != ", len(x))
+		}
+	}
+	calls := 0
+	t.ForEachDistinct(func(interface{}, int) bool { calls++; return false })
+	if calls != 1 {
+		s.Error(calls, " != 1")
+	}
+	New().ForEachDistinct(func(interface{}, int) bool { s.Error("called on empty list"); return true })
+}
+
+func TestT_ReverseIter(s *testing.T) {
+	s.Parallel()
+	if _, ok := New().ReverseIter().Next(); ok {
+		s.Error("Next of empty list")
+	}
+	i := itreap(100).ReverseIter()
+	for x := 99; x >= 0; x-- {
+		v, ok := i.Next()
+		if !ok || v != x {
+			s.Error(v, " != ", x)
+		}
+	}
+	if _, ok := i.Next(); ok {
+		s.Error("Next past end")
+	}
+}
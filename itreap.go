@@ -19,9 +19,16 @@ package itreap
 // root.  This keeps the tree balanced regardless which values are inserted.
 
 import (
+	"cmp"
 	"fmt"
 	"github.com/glenn-brown/ordinal"
+	"io"
+	"math"
 	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 )
 
 // An itreap can hold any type that implements the Slow interface, but
@@ -50,12 +57,132 @@ type T struct {
 	value       interface{}
 	score       float64
 	left, right *T
+	cfg         *config
+	own, weight int // weight of the value, and of the subtree
 }
 
+// A config holds the ordering shared by every node of a treap.  A treap
+// built by NewWithLess is ordered by less alone, and one built by
+// NewHashed draws the priorities of new nodes from hash.  An ordinal
+// treap has no config until its first insertion, which for a value type
+// with a known score caches the type, its ordinal less function, and a
+// direct score function, so later insertions of that type skip
+// ordinal.FnScore.
+//
+type config struct {
+	less func(a, b interface{}) bool
+	hash func(value interface{}) int32
+
+	typ     reflect.Type
+	typLess func(a, b interface{}) bool
+	score   func(value interface{}) float64
+}
+
+// Score functions for the value types whose ordinal score is simply
+// their numeric value.
+//
+var scores = map[reflect.Type]func(value interface{}) float64{
+	reflect.TypeOf(int(0)):     func(v interface{}) float64 { return float64(v.(int)) },
+	reflect.TypeOf(int32(0)):   func(v interface{}) float64 { return float64(v.(int32)) },
+	reflect.TypeOf(int64(0)):   func(v interface{}) float64 { return float64(v.(int64)) },
+	reflect.TypeOf(float32(0)): func(v interface{}) float64 { return float64(v.(float32)) },
+	reflect.TypeOf(float64(0)): func(v interface{}) float64 { return v.(float64) },
+}
+
+// Return a config caching the comparison of values of the type of
+// value, or nil if the type has no known score.
+//
+func cache(value interface{}) *config {
+	typ := reflect.TypeOf(value)
+	score, ok := scores[typ]
+	if !ok {
+		return nil
+	}
+	less, _ := ordinal.FnScore(value)
+	return &config{typ: typ, typLess: less, score: score}
+}
+
+// Return true iff empty treaps must keep config c, because it was chosen
+// at construction rather than cached by a first insertion.
+//
+func (c *config) kept() bool { return nil != c.less || nil != c.hash }
+
 // Return nil, the empty immutable list.
 //
 func New() *T { return nil }
 
+// NewWithLess returns an empty immutable list ordered only by less, which
+// must return true iff a is less than b.  Values are compared with less
+// alone, skipping the ordinal package and its scores, so any type that
+// less accepts may be stored.  Lists combined with the list, as by Join
+// or Union, must have the same ordering.
+//
+func NewWithLess(less func(a, b interface{}) bool) *T {
+	return &T{cfg: &config{less: less}}
+}
+
+// NewHashed returns an empty immutable list whose nodes take their
+// priorities from hash(value) rather than at random.  Values are
+// ordered as in New.  Because a treap with distinct values and
+// priorities has only one shape, the list then has the same shape for
+// the same values in any order of insertion, even across processes.
+// But the balance of the tree rests on hash: values with colliding
+// hashes, and equal values, which always collide, have tied priorities,
+// and a hash that is not well spread can leave the tree unbalanced.
+//
+func NewHashed(hash func(value interface{}) int32) *T {
+	return &T{cfg: &config{hash: hash}}
+}
+
+// Return the comparison function and score for value in treap t.  Treaps
+// built by NewWithLess give every value a score of 0, so comparisons
+// fall through to the less function.  Values of a treap's cached type
+// are compared without ordinal.FnScore, and other values, as in a
+// treap of mixed types, fall back to it.
+//
+func (t *T) fnScore(value interface{}) (less func(a, b interface{}) bool, score float64) {
+	if c := t.config(); nil != c {
+		if nil != c.less {
+			return c.less, 0
+		}
+		if reflect.TypeOf(value) == c.typ {
+			return c.typLess, c.score(value)
+		}
+	}
+	return ordinal.FnScore(value)
+}
+
+// Return the config of treap t, or nil for an ordinal treap.
+//
+func (t *T) config() *config {
+	if nil == t {
+		return nil
+	}
+	return t.cfg
+}
+
+// Return the root node of treap t, or nil if t is empty.  An empty
+// treap built by NewWithLess or NewHashed is a node with no value and a
+// count of 0, which keeps the config.
+//
+func (t *T) root() *T {
+	if nil == t || 0 == t.count {
+		return nil
+	}
+	return t
+}
+
+// Return treap nu, derived from treap t, or if nu is empty and t was
+// built by NewWithLess or NewHashed, an empty treap that keeps the
+// config.
+//
+func (t *T) rooted(nu *T) *T {
+	if nil == nu && nil != t.config() && t.cfg.kept() {
+		return &T{cfg: t.cfg}
+	}
+	return nu
+}
+
 // Move sorted-but-misprioritized node t in sorted tree t down to its
 // appropriate heap level in heap t.  t.left and t.right are valid
 // treaps.
@@ -72,6 +199,7 @@ func (t *T) prioritize() *T {
 		goto right
 	}
 	if nil == right || right.priority <= t.priority || left.priority > right.priority {
+		rotated()
 		return &T{
 			t.count,
 			left.priority,
@@ -83,26 +211,35 @@ func (t *T) prioritize() *T {
 				t.value,
 				t.score,
 				left.right,
-				t.right}).prioritize()}
+				t.right,
+				t.cfg,
+				t.own,
+				t.own + weigh(left.right, t.right)}).prioritize(),
+			left.cfg,
+			left.own,
+			t.weight}
 	}
 right:
+	rotated()
 	return &T{
 		t.count,
 		right.priority,
 		right.value,
 		right.score,
 		(&T{1 + sum(t.left, right.left), t.priority, t.value,
-			t.score, t.left, right.left}).prioritize(),
-		right.right}
+			t.score, t.left, right.left, t.cfg,
+			t.own, t.own + weigh(t.left, right.left)}).prioritize(),
+		right.right, right.cfg, right.own, t.weight}
 }
 
 // Contains returns true iff the tree contains the specified value, in O(log(N)) time.
 //
 func (a *T) Contains(value interface{}) bool {
-	if a == nil {
+	if nil == a.root() {
 		return false
 	}
-	lessFn, s := ordinal.FnScore(value)
+	lessFn, s := a.fnScore(value)
+	a = a.root()
 	for {
 		switch {
 		case a == nil:
@@ -126,48 +263,396 @@ func (a *T) Contains(value interface{}) bool {
 	panic("never")
 }
 
+// Get returns the value stored in the list that is equal to query, in
+// O(log(N)) time.  The stored value may be distinct from query, as when
+// the ordering compares only a key, so Get recovers canonical values.
+// If there are multiple equal values, any one is returned.  If there
+// is none, found is false.
+//
+func (a *T) Get(query interface{}) (stored interface{}, found bool) {
+	less, s := a.fnScore(query)
+	for a = a.root(); nil != a; {
+		switch {
+		case a.precedes(query, s, less):
+			a = a.right
+		case a.follows(query, s, less):
+			a = a.left
+		default:
+			return a.value, true
+		}
+	}
+	return nil, false
+}
+
+// Rank returns the number of values in the list that are less than
+// value, which is the position at which value would be inserted, in
+// O(log(N)) time.
+//
+func (a *T) Rank(value interface{}) int {
+	less, s := a.fnScore(value)
+	return a.root().lower(value, s, less)
+}
+
+// IndexOf returns the position of the first value in the list equal to
+// value, or -1 if there is none, in O(log(N)) time.
+//
+func (a *T) IndexOf(value interface{}) int {
+	less, s := a.fnScore(value)
+	index, found := 0, false
+	for a = a.root(); nil != a; {
+		if a.precedes(value, s, less) {
+			index += 1 + a.left.Len()
+			a = a.right
+		} else {
+			found = found || !a.follows(value, s, less)
+			a = a.left
+		}
+	}
+	if !found {
+		return -1
+	}
+	return index
+}
+
+// Count returns the number of values in the list equal to value, in
+// O(log(N)) time.
+//
+func (a *T) Count(value interface{}) int {
+	less, s := a.fnScore(value)
+	a = a.root()
+	return a.upper(value, s, less) - a.lower(value, s, less)
+}
+
+// EqualRange returns the half-open interval [lo,hi) of positions in the
+// list holding values equal to value, in O(log(N)) time, so that
+// hi-lo == Count(value).  If value is absent, lo and hi are both the
+// position at which it would be inserted.
+//
+func (a *T) EqualRange(value interface{}) (lo, hi int) {
+	less, s := a.fnScore(value)
+	a = a.root()
+	return a.lower(value, s, less), a.upper(value, s, less)
+}
+
+// Return the number of values in treap a less than value, which has
+// score s and comparison function less.
+//
+func (a *T) lower(value interface{}, s float64, less func(a, b interface{}) bool) (rank int) {
+	for nil != a {
+		if a.precedes(value, s, less) {
+			rank += 1 + a.left.Len()
+			a = a.right
+		} else {
+			a = a.left
+		}
+	}
+	return rank
+}
+
+// Return the number of values in treap a no greater than value, which
+// has score s and comparison function less.
+//
+func (a *T) upper(value interface{}, s float64, less func(a, b interface{}) bool) (rank int) {
+	for nil != a {
+		if !a.follows(value, s, less) {
+			rank += 1 + a.left.Len()
+			a = a.right
+		} else {
+			a = a.left
+		}
+	}
+	return rank
+}
+
+// RangeCount returns the number of values v in the list with
+// lo <= v < hi, in O(log(N)) time.  If lo >= hi, it returns 0.  Nil
+// bounds are unsupported, and RangeCount panics if either is nil.
+//
+func (a *T) RangeCount(lo, hi interface{}) int {
+	if nil == lo || nil == hi {
+		panic("itreap: RangeCount with nil bound")
+	}
+	r := a.newInterval(lo, hi)
+	if r.empty() {
+		return 0
+	}
+	// Descend to the first node in range, then count the values above lo
+	// to its left and below hi to its right.
+	a = r.top(a.root())
+	if nil == a {
+		return 0
+	}
+	count := 1
+	for n := a.left; nil != n; {
+		if r.below(n) {
+			n = n.right
+		} else {
+			count += 1 + n.right.Len()
+			n = n.left
+		}
+	}
+	for n := a.right; nil != n; {
+		if r.above(n) {
+			n = n.left
+		} else {
+			count += 1 + n.left.Len()
+			n = n.right
+		}
+	}
+	return count
+}
+
+// AnyInRange returns true iff the list holds a value v with lo <= v < hi,
+// in O(log(N)) time.  It stops at the first such value found, without
+// counting the range.  If lo >= hi, it returns false.
+//
+func (a *T) AnyInRange(lo, hi interface{}) bool {
+	r := a.newInterval(lo, hi)
+	return !r.empty() && nil != r.top(a.root())
+}
+
+// Predecessor returns the greatest value in the list that is less than
+// value, in O(log(N)) time.  The value need not be in the list.  If no
+// lesser value is stored, ok is false.
+//
+func (a *T) Predecessor(value interface{}) (v interface{}, ok bool) {
+	less, s := a.fnScore(value)
+	a = a.root()
+	for nil != a {
+		if a.precedes(value, s, less) {
+			v, ok = a.value, true
+			a = a.right
+		} else {
+			a = a.left
+		}
+	}
+	return v, ok
+}
+
+// Successor returns the least value in the list that is greater than
+// value, in O(log(N)) time.  The value need not be in the list.  If no
+// greater value is stored, ok is false.
+//
+func (a *T) Successor(value interface{}) (v interface{}, ok bool) {
+	less, s := a.fnScore(value)
+	a = a.root()
+	for nil != a {
+		if a.follows(value, s, less) {
+			v, ok = a.value, true
+			a = a.left
+		} else {
+			a = a.right
+		}
+	}
+	return v, ok
+}
+
+// Floor returns the greatest value in the list that is no greater than
+// value, in O(log(N)) time.  If every stored value is greater, ok is
+// false.
+//
+func (a *T) Floor(value interface{}) (v interface{}, ok bool) {
+	less, s := a.fnScore(value)
+	a = a.root()
+	for nil != a {
+		if !a.follows(value, s, less) {
+			v, ok = a.value, true
+			a = a.right
+		} else {
+			a = a.left
+		}
+	}
+	return v, ok
+}
+
+// Ceiling returns the least value in the list that is no less than
+// value, in O(log(N)) time.  If every stored value is less, ok is false.
+//
+func (a *T) Ceiling(value interface{}) (v interface{}, ok bool) {
+	less, s := a.fnScore(value)
+	a = a.root()
+	for nil != a {
+		if !a.precedes(value, s, less) {
+			v, ok = a.value, true
+			a = a.left
+		} else {
+			a = a.right
+		}
+	}
+	return v, ok
+}
+
+// Sources of random priorities, pooled so that goroutines inserting
+// concurrently do not contend for the lock of the global source.  Each
+// is seeded from the global source when the pool creates it.
+//
+var sources = sync.Pool{New: func() interface{} {
+	return rand.New(rand.NewSource(rand.Int63()))
+}}
+
+// Return a random priority for a new node.
+//
+func priority() int32 {
+	r := sources.Get().(*rand.Rand)
+	p := r.Int31()
+	sources.Put(r)
+	return p
+}
+
+// Return the priority for a new node holding value in treap t: from the
+// hash of a NewHashed treap, or else at random.
+//
+func (t *T) newPriority(value interface{}) int32 {
+	if c := t.config(); nil != c && nil != c.hash {
+		return c.hash(value)
+	}
+	return priority()
+}
+
 // Insert returns a new tree like the original, but with the value inserted, in O(log(N)) time.
 //
 func (t *T) Insert(value interface{}) *T {
-	less, score := ordinal.FnScore(value)
-	nu := &T{1, rand.Int31(), value, score, nil, nil}
-	return t.insert(nu, less)
+	return t.insertPriority(value, t.newPriority(value), 1)
 }
 
-// Return a new immutable treap like treap t, but with node nu inserted, in O(log(N)) time.
+// InsertRand is like Insert, but draws the priority of the new node from
+// r rather than from the package's own sources, so trees built from
+// identically seeded sources by the same insertions have identical
+// shapes.
 //
-func (t *T) insert(nu *T, less func(a, b interface{}) bool) *T {
+func (t *T) InsertRand(r *rand.Rand, value interface{}) *T {
+	return t.insertPriority(value, r.Int31(), 1)
+}
+
+// InsertWeighted is like Insert, but gives the value the weight weight
+// rather than 1, so that GetWeighted indexes the list as if the value
+// were repeated weight times.  weight must not be negative.  Weights
+// are kept by operations that move values between lists, such as Split
+// and Union, but values inserted anew, as by Map or UnmarshalJSON, have
+// weight 1.
+//
+func (t *T) InsertWeighted(value interface{}, weight int) *T {
+	if weight < 0 {
+		panic(fmt.Sprintf("itreap: negative weight %d", weight))
+	}
+	return t.insertPriority(value, t.newPriority(value), weight)
+}
+
+func (t *T) insertPriority(value interface{}, priority int32, weight int) *T {
+	cfg := t.config()
 	if nil == t {
-		return nu
+		cfg = cache(value)
 	}
+	less, score := t.fnScore(value)
+	nu := &T{1, priority, value, score, nil, nil, cfg, weight, weight}
+	return t.root().insert(nu, less)
+}
 
-	// Insert on left if less than root, and right if greater, taking care to
-	// handle score cases first for performance.
+// InsertUnique is like Insert, but if the list already contains a value
+// equal to value it returns the original list and added is false.
+//
+func (t *T) InsertUnique(value interface{}) (nu *T, added bool) {
+	if t.Contains(value) {
+		return t, false
+	}
+	return t.Insert(value), true
+}
 
-	if nu.score < t.score {
-		goto left
+// Replace returns a new tree like the original, but with a value equal
+// to value replaced by value itself, in O(log(N)) time.  The replaced
+// node keeps its priority and position, so the shape of the tree is
+// unchanged.  This suits values carrying data that does not affect
+// their order; because the replaced value is found by comparing it with
+// value, the two necessarily compare equal and the tree stays ordered.
+// If there is no equal value, the original tree is returned and replaced
+// is false.  If there are several, only one is replaced.
+//
+func (t *T) Replace(value interface{}) (nu *T, replaced bool) {
+	less, score := t.fnScore(value)
+	nu = t.root().replace(value, score, less)
+	if nil == nu {
+		return t, false
+	}
+	return nu, true
+}
+
+func (t *T) replace(value interface{}, score float64, less func(a, b interface{}) bool) *T {
+	if nil == t {
+		return nil
 	}
-	if t.score < nu.score || less(t.value, nu.value) {
-		right := t.right.insert(nu, less)
-		if right.priority > t.priority {
-			// Rotate left, replacing t.right with right.
-			return &T{
-				t.count + 1, right.priority, right.value, right.score,
-				&T{1 + sum(t.left, right.left), t.priority, t.value, t.score, t.left, right.left},
-				right.right}
+	if t.precedes(value, score, less) {
+		right := t.right.replace(value, score, less)
+		if nil == right {
+			return nil
 		}
-		return &T{t.count + 1, t.priority, t.value, t.score, t.left, right}
+		return &T{t.count, t.priority, t.value, t.score, t.left, right, t.cfg, t.own, t.weight}
 	}
-left:
-	left := t.left.insert(nu, less)
-	if left.priority > t.priority {
-		// Rotate right, replacing t.left with left.
-		return &T{
-			t.count + 1, left.priority, left.value, left.score, left.left,
-			&T{1 + sum(left.right, t.right), t.priority, t.value, t.score,
-				left.right, t.right}}
+	if t.follows(value, score, less) {
+		left := t.left.replace(value, score, less)
+		if nil == left {
+			return nil
+		}
+		return &T{t.count, t.priority, t.value, t.score, left, t.right, t.cfg, t.own, t.weight}
 	}
-	return &T{t.count + 1, t.priority, t.value, t.score, left, t.right}
+	return &T{t.count, t.priority, value, t.score, t.left, t.right, t.cfg, t.own, t.weight}
+}
+
+// Return a new immutable treap like treap t, but with node nu inserted, in O(log(N)) time.
+//
+func (t *T) insert(nu *T, less func(a, b interface{}) bool) *T {
+	// Descend to the insertion point, recording the path of ancestors and
+	// whether the new node goes to the right of each.  Insert on left if
+	// less than the ancestor, and right if greater, taking care to handle
+	// score cases first for performance.
+
+	var pathBuf [64]*T
+	var rightBuf [64]bool
+	path, right := pathBuf[:0], rightBuf[:0]
+	for n := t; nil != n; {
+		r := n.score < nu.score || !(nu.score < n.score) && less(n.value, nu.value)
+		path, right = append(path, n), append(right, r)
+		if r {
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+
+	// Rebuild the path bottom-up, rotating the new node up past ancestors
+	// of lower priority.
+
+	sub := nu
+	for i := len(path) - 1; 0 <= i; i-- {
+		t := path[i]
+		if right[i] {
+			if sub.priority > t.priority {
+				// Rotate left, replacing t.right with sub.
+				rotated()
+				sub = &T{
+					t.count + 1, sub.priority, sub.value, sub.score,
+					&T{1 + sum(t.left, sub.left), t.priority, t.value, t.score, t.left, sub.left, t.cfg,
+						t.own, t.own + weigh(t.left, sub.left)},
+					sub.right, sub.cfg, sub.own, t.weight + nu.own}
+			} else {
+				sub = &T{t.count + 1, t.priority, t.value, t.score, t.left, sub, t.cfg,
+					t.own, t.weight + nu.own}
+			}
+		} else {
+			if sub.priority > t.priority {
+				// Rotate right, replacing t.left with sub.
+				rotated()
+				sub = &T{
+					t.count + 1, sub.priority, sub.value, sub.score, sub.left,
+					&T{1 + sum(sub.right, t.right), t.priority, t.value, t.score,
+						sub.right, t.right, t.cfg, t.own, t.own + weigh(sub.right, t.right)},
+					sub.cfg, sub.own, t.weight + nu.own}
+			} else {
+				sub = &T{t.count + 1, t.priority, t.value, t.score, sub, t.right, t.cfg,
+					t.own, t.weight + nu.own}
+			}
+		}
+	}
+	return sub
 }
 
 // Remove returns a new treap like the original, but with the value removed, in O(log(N)) time.
@@ -175,31 +660,87 @@ left:
 // If there are multiple matching values, only one is removed.
 //
 func (t *T) Remove(value interface{}) *T {
-	less, score := ordinal.FnScore(value)
-	rv, ok := t.remove(value, score, less)
+	less, score := t.fnScore(value)
+	rv, ok := t.root().remove(value, score, less)
 	if !ok {
 		return t
 	}
-	return rv
+	return t.rooted(rv)
 }
 
-func (t *T) remove(value interface{}, score float64, less func(a, b interface{}) bool) (*T, bool) {
-	if nil == t {
-		return nil, false
+// RemoveAll returns a new treap like the original, but with every value
+// equal to value removed, along with the number removed, in O(log(N))
+// time.  If there is no matching value, the original tree is returned.
+//
+func (t *T) RemoveAll(value interface{}) (nu *T, removed int) {
+	less, score := t.fnScore(value)
+	left, rest := t.root().split(value, score, less)
+	equal, right := rest.splitAfter(value, score, less)
+	if nil == equal {
+		return t, 0
 	}
-	if score < t.score {
-		goto left
+	return t.rooted(join(left, right)), equal.Len()
+}
+
+// RemoveSlice returns a new treap like the original, but with one
+// matching value removed for each occurrence of a value in values, as
+// by repeated calls to Remove, along with the number removed, in
+// O(M*log(N)) time.  Values with no match remaining are not counted.
+// If nothing is removed, the original tree is returned.
+//
+func (t *T) RemoveSlice(values []interface{}) (nu *T, removed int) {
+	root := t.root()
+	for _, v := range values {
+		less, score := t.fnScore(v)
+		if n, ok := root.remove(v, score, less); ok {
+			root = n
+			removed++
+		}
 	}
-	if t.score < score || less(t.value, value) {
-		right, ok := t.right.remove(value, score, less)
-		return &T{t.count - 1, t.priority, t.value, t.score, t.left, right}, ok
+	if 0 == removed {
+		return t, 0
 	}
-	if !less(value, t.value) {
-		return t.removeNode(), true
+	return t.rooted(root), removed
+}
+
+func (t *T) remove(value interface{}, score float64, less func(a, b interface{}) bool) (*T, bool) {
+	// Descend to the matching node, recording the path of ancestors and
+	// whether the match lies to the right of each.
+
+	var pathBuf [64]*T
+	var rightBuf [64]bool
+	path, right := pathBuf[:0], rightBuf[:0]
+	n := t
+	for {
+		if nil == n {
+			return t, false
+		}
+		r := n.score < score || !(score < n.score) && less(n.value, value)
+		if !r && !(score < n.score) && !less(value, n.value) {
+			break
+		}
+		path, right = append(path, n), append(right, r)
+		if r {
+			n = n.right
+		} else {
+			n = n.left
+		}
+	}
+
+	// Remove the match and rebuild the path bottom-up.
+
+	sub := n.removeNode()
+	for i := len(path) - 1; 0 <= i; i-- {
+		t := path[i]
+		if right[i] {
+			sub = &T{t.count - 1, t.priority, t.value, t.score, t.left, sub, t.cfg,
+				t.own, t.own + weigh(t.left, sub)}
+		} else {
+			sub = &T{t.count - 1, t.priority, t.value, t.score, sub, t.right, t.cfg,
+				t.own, t.own + weigh(sub, t.right)}
+		}
 	}
-left:
-	left, ok := t.left.remove(value, score, less)
-	return &T{t.count - 1, t.priority, t.value, t.score, left, t.right}, ok
+	return sub, true
 }
 
 func (t *T) removeNode() *T {
@@ -213,7 +754,8 @@ func (t *T) removeNode() *T {
 	// Find and remove the successor node.
 	n, right := right.removeLeftmost()
 	// Repace the top (removed) node with the successor, and restore priority.
-	return (&T{t.count - 1, n.priority, n.value, n.score, left, right}).prioritize()
+	return (&T{t.count - 1, n.priority, n.value, n.score, left, right, n.cfg,
+		n.own, n.own + weigh(left, right)}).prioritize()
 }
 
 func (t *T) removeLeftmost() (left *T, after *T) {
@@ -224,7 +766,8 @@ func (t *T) removeLeftmost() (left *T, after *T) {
 		return t, t.right
 	}
 	n, left := t.left.removeLeftmost()
-	return n, &T{t.count - 1, t.priority, t.value, t.score, left, t.right}
+	return n, &T{t.count - 1, t.priority, t.value, t.score, left, t.right, t.cfg,
+		t.own, t.own + weigh(left, t.right)}
 }
 
 func (t *T) removeRightmost() (right *T, after *T) {
@@ -235,7 +778,8 @@ func (t *T) removeRightmost() (right *T, after *T) {
 		return t, t.left
 	}
 	n, right := t.right.removeRightmost()
-	return n, &T{t.count - 1, t.priority, t.value, t.score, t.left, right}
+	return n, &T{t.count - 1, t.priority, t.value, t.score, t.left, right, t.cfg,
+		t.own, t.own + weigh(t.left, right)}
 }
 
 // Len returns the number of values in the list.
@@ -248,50 +792,384 @@ func (t *T) Len() int {
 }
 
 // RemoveN removes the nth element from the list, returning the
-// modified list and removed value.  Use t.RemoveN(0) to pop the first (least)
-// value and t.RemoveN(t.Len()-1) to remove the last (greatest).
+// modified list and removed value.  Use t.RemoveN(0) to pop the first
+// (least) value and t.RemoveN(t.Len()-1) to remove the last (greatest).
+// If n is out of range, the original list is returned with a nil value.
 //
 func (t *T) RemoveN(n int) (nu *T, val interface{}) {
 	if nil == t || n < 0 || t.count <= n {
-		return nil, nil
+		return t, nil
 	}
+	nu, val = t.removeN(n)
+	return t.rooted(nu), val
+}
+
+func (t *T) removeN(n int) (nu *T, val interface{}) {
 	lcount := 0
 	if nil != t.left {
 		lcount = t.left.count
 	}
 	if n < lcount {
-		left, val := t.left.RemoveN(n)
-		return &T{t.count - 1, t.priority, t.value, t.score, left, t.right}, val
+		left, val := t.left.removeN(n)
+		return &T{t.count - 1, t.priority, t.value, t.score, left, t.right, t.cfg,
+			t.own, t.own + weigh(left, t.right)}, val
 	}
 	if n > lcount {
-		right, val := t.right.RemoveN(n - lcount - 1)
-		return &T{t.count - 1, t.priority, t.value, t.score, t.left, right}, val
+		right, val := t.right.removeN(n - lcount - 1)
+		return &T{t.count - 1, t.priority, t.value, t.score, t.left, right, t.cfg,
+			t.own, t.own + weigh(t.left, right)}, val
 	}
 	return t.removeNode(), t.value
 }
 
+// Uncons returns the least value in the list and a new list like the
+// original but with that value removed, in O(log(N)) time.  If the list
+// is empty, ok is false.
+//
+func (t *T) Uncons() (head interface{}, tail *T, ok bool) {
+	if nil == t.root() {
+		return nil, t, false
+	}
+	n, tail := t.removeLeftmost()
+	return n.value, t.rooted(tail), true
+}
+
+// PopMin removes the least value from the list, returning the value and
+// the modified list, in O(log(N)) time.  If the list is empty, ok is
+// false.
+//
+func (t *T) PopMin() (value interface{}, rest *T, ok bool) {
+	return t.Uncons()
+}
+
+// PopMax removes the greatest value from the list, returning the value
+// and the modified list, in O(log(N)) time.  If the list is empty, ok is
+// false.
+//
+func (t *T) PopMax() (value interface{}, rest *T, ok bool) {
+	if nil == t.root() {
+		return nil, t, false
+	}
+	n, rest := t.removeRightmost()
+	return n.value, t.rooted(rest), true
+}
+
 // Return the value at position n in the list.  The index n must be in the interval
-// [0,t.Len()).
+// [-t.Len(),t.Len()), where negative indices count back from the end of
+// the list, so GetN(-1) returns the last value.  Out-of-range indices
+// return nil.
 //
 func (t *T) GetN(n int) (value interface{}) {
+	if n < 0 {
+		n += t.Len()
+	}
+	return t.getN(n)
+}
+
+// Return the value at position n of treap t, or nil if there is none.
+//
+func (t *T) getN(n int) (value interface{}) {
+	if node := t.nodeN(n); nil != node {
+		return node.value
+	}
+	return nil
+}
+
+// Return the node at position n of treap t, or nil if there is none.
+//
+func (t *T) nodeN(n int) *T {
+	for t = t.root(); nil != t; {
+		lcount := t.left.Len()
+		switch {
+		case n < lcount:
+			t = t.left
+		case lcount < n:
+			n -= lcount + 1
+			t = t.right
+		default:
+			return t
+		}
+	}
+	return nil
+}
+
+// GetNFloat is like GetN, but returns the value as a float64.  If n is
+// out of range or the value is not a float64, ok is false.
+//
+func (t *T) GetNFloat(n int) (value float64, ok bool) {
+	value, ok = t.GetN(n).(float64)
+	return value, ok
+}
+
+// GetNInt is like GetN, but returns the value as an int.  If n is out
+// of range or the value is not an int, ok is false.
+//
+func (t *T) GetNInt(n int) (value int, ok bool) {
+	value, ok = t.GetN(n).(int)
+	return value, ok
+}
+
+// GetMany returns the values at the given positions in the list, in the
+// order requested, in a single traversal that skips subtrees holding no
+// requested position.  Positions not in the interval [0,t.Len()) give
+// nil.
+//
+func (t *T) GetMany(indices []int) []interface{} {
+	values := make([]interface{}, len(indices))
+	order := make([]int, len(indices))
+	for k := range order {
+		order[k] = k
+	}
+	slices.SortFunc(order, func(a, b int) int { return cmp.Compare(indices[a], indices[b]) })
+	for 0 < len(order) && indices[order[0]] < 0 {
+		order = order[1:]
+	}
+	t.root().getMany(0, indices, order, values)
+	return values
+}
+
+// Set values[k] to the value of treap t at position indices[k]-offset,
+// for each k in order, which is sorted by index, until the positions
+// pass the end of t.  Return the rest of order.
+//
+func (t *T) getMany(offset int, indices, order []int, values []interface{}) []int {
 	if nil == t {
+		return order
+	}
+	here := offset + t.left.Len()
+	if 0 < len(order) && indices[order[0]] < here {
+		order = t.left.getMany(offset, indices, order, values)
+	}
+	for 0 < len(order) && indices[order[0]] == here {
+		values[order[0]] = t.value
+		order = order[1:]
+	}
+	if 0 < len(order) && indices[order[0]] < offset+t.count {
+		order = t.right.getMany(here+1, indices, order, values)
+	}
+	return order
+}
+
+// MustGetN is like GetN, but like indexing a slice, it panics if n is
+// not in the interval [0,t.Len()).
+//
+func (t *T) MustGetN(n int) (value interface{}) {
+	t.checkIndex(n)
+	return t.getN(n)
+}
+
+// MustRemoveN is like RemoveN, but like indexing a slice, it panics if
+// n is not in the interval [0,t.Len()).
+//
+func (t *T) MustRemoveN(n int) (nu *T, val interface{}) {
+	t.checkIndex(n)
+	return t.RemoveN(n)
+}
+
+// Panic unless n is a position in treap t.
+//
+func (t *T) checkIndex(n int) {
+	if n < 0 || t.Len() <= n {
+		panic(fmt.Sprintf("itreap: index %d out of range [0,%d)", n, t.Len()))
+	}
+}
+
+// GetWeighted returns the value at weighted position n in the list, in
+// O(log(N)) time, indexing the list as if each value were repeated as
+// many times as its weight.  The index n must be in the interval
+// [0,t.TotalWeight()); out-of-range indices return nil.
+//
+func (t *T) GetWeighted(n int) (value interface{}) {
+	if n < 0 {
 		return nil
 	}
-	lcount := 0
-	if nil != t.left {
-		lcount = t.left.count
+	for t = t.root(); nil != t; {
+		lweight := weigh(t.left, nil)
+		switch {
+		case n < lweight:
+			t = t.left
+		case n < lweight+t.own:
+			return t.value
+		default:
+			n -= lweight + t.own
+			t = t.right
+		}
 	}
-	if n < lcount {
-		return t.left.GetN(n)
+	return nil
+}
+
+// TotalWeight returns the sum of the weights of the values in the list,
+// which is t.Len() if every value has the default weight of 1.
+//
+func (t *T) TotalWeight() int { return weigh(t.root(), nil) }
+
+// Quantile returns the value at fractional rank q of the list, in
+// O(log(N)) time, so Quantile(0.5) is the median.  It uses the nearest
+// rank rule, without interpolation: the result is the value at position
+// ceil(q*N)-1, or the least value if q is 0.  q is clamped to [0,1],
+// and an empty list returns nil.
+//
+func (t *T) Quantile(q float64) interface{} {
+	n := t.Len()
+	if 0 == n {
+		return nil
+	}
+	q = math.Max(0, math.Min(q, 1))
+	i := int(math.Ceil(q*float64(n))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return t.GetN(i)
+}
+
+// RandomSample returns k values of the list chosen uniformly at random
+// without replacement, using r, in O(k*log(N)) time.  If k >= t.Len(),
+// it returns all the values, and if k <= 0, an empty slice.  The
+// sampled values are not in any particular order.
+//
+func (t *T) RandomSample(r *rand.Rand, k int) []interface{} {
+	n := t.Len()
+	if k >= n {
+		return t.ToSlice()
+	}
+	if k <= 0 {
+		return []interface{}{}
+	}
+	// Floyd's algorithm picks k distinct positions with k draws.
+	values := make([]interface{}, 0, k)
+	chosen := make(map[int]bool, k)
+	for j := n - k; j < n; j++ {
+		i := r.Intn(j + 1)
+		if chosen[i] {
+			i = j
+		}
+		chosen[i] = true
+		values = append(values, t.GetN(i))
+	}
+	return values
+}
+
+// SliceN returns the values at positions [i,j) of the list, in
+// O(log(N)+j-i) time.  Indices are clamped to [0,t.Len()], and an empty
+// slice is returned if i >= j.
+//
+func (t *T) SliceN(i, j int) []interface{} {
+	if i < 0 {
+		i = 0
+	}
+	if j > t.Len() {
+		j = t.Len()
 	}
-	if lcount < n {
-		return t.right.GetN(n - lcount - 1)
+	if i >= j {
+		return []interface{}{}
 	}
-	return t.value
+	return t.sliceN(i, j, make([]interface{}, 0, j-i))
+}
+
+// GetRangeByValue returns the values v of the list with lo <= v < hi,
+// in order, in O(log(N)+K) time for K values returned.  The bounds need
+// not be in the list, and an empty slice is returned if lo >= hi.
+//
+func (t *T) GetRangeByValue(lo, hi interface{}) []interface{} {
+	values := []interface{}{}
+	t.ForEachRange(lo, hi, func(v interface{}) bool {
+		values = append(values, v)
+		return true
+	})
+	return values
+}
+
+// Append the values at positions [i,j) of treap t to values, skipping
+// subtrees outside the range.
+//
+func (t *T) sliceN(i, j int, values []interface{}) []interface{} {
+	if nil == t || j <= 0 || t.count <= i {
+		return values
+	}
+	lcount := t.left.Len()
+	values = t.left.sliceN(i, j, values)
+	if i <= lcount && lcount < j {
+		values = append(values, t.value)
+	}
+	return t.right.sliceN(i-lcount-1, j-lcount-1, values)
+}
+
+// LongestConsecutive returns the least value and length of the longest
+// run of consecutive integers stored in the list, in O(N) time.  It
+// applies to values of type int, each one greater than the one before
+// it in a run, or one less in a list ordered from greatest to least, as
+// by Reverse; values of other types break runs, and duplicate values
+// neither extend nor break a run.  The earliest of equally long runs is
+// returned, and (0,0) is returned for a list with no int values.
+//
+func (t *T) LongestConsecutive() (start, length int) {
+	var least, prev, run, step int
+	w := t.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		v, ok := n.value.(int)
+		switch {
+		case !ok:
+			run = 0
+			continue
+		case 0 < run && v == prev:
+			continue
+		case 0 < run && (v-prev == step || 0 == step && (v-prev == 1 || v-prev == -1)):
+			step = v - prev
+			run++
+		default:
+			least, run, step = v, 1, 0
+		}
+		if v < least {
+			least = v
+		}
+		if run > length {
+			start, length = least, run
+		}
+		prev = v
+	}
+	return start, length
+}
+
+// Min returns the least value in the list, in O(log(N)) time.  If the
+// list is empty, ok is false.
+//
+func (t *T) Min() (value interface{}, ok bool) {
+	if nil == t.root() {
+		return nil, false
+	}
+	return t.first().value, true
+}
+
+// Max returns the greatest value in the list, in O(log(N)) time.  If
+// the list is empty, ok is false.
+//
+func (t *T) Max() (value interface{}, ok bool) {
+	if nil == t.root() {
+		return nil, false
+	}
+	return t.last().value, true
+}
+
+// Return the leftmost node of nonempty treap t.
+//
+func (t *T) first() *T {
+	for nil != t.left {
+		t = t.left
+	}
+	return t
+}
+
+// Return the rightmost node of nonempty treap t.
+//
+func (t *T) last() *T {
+	for nil != t.right {
+		t = t.right
+	}
+	return t
 }
 
 func (t *T) Print() {
-	if nil == t {
+	if nil == t.root() {
 		return
 	}
 	t.left.Print()
@@ -302,7 +1180,7 @@ func (t *T) Print() {
 // Return a string representation of the immutable treap.
 //
 func (t *T) String() string {
-	if nil == t {
+	if nil == t.root() {
 		return ""
 	}
 	left, right := t.left, t.right
@@ -318,6 +1196,52 @@ func (t *T) String() string {
 	return fmt.Sprintf("%v %v %v", left, t.value, right)
 }
 
+// ToSlice returns the values of the list in order, in O(N) time.  It
+// returns an empty slice for an empty list.
+//
+func (t *T) ToSlice() []interface{} {
+	values := make([]interface{}, 0, t.Len())
+	w := t.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		values = append(values, n.value)
+	}
+	return values
+}
+
+// WriteTo writes the values of the list to w in order, in the
+// space-separated form of String, without building the whole string in
+// memory.  It returns the number of bytes written and stops at the
+// first write error, which it returns.
+//
+func (t *T) WriteTo(w io.Writer) (n int64, err error) {
+	sep := ""
+	walk := t.walk()
+	for node := walk.next(); nil != node; node = walk.next() {
+		m, err := fmt.Fprintf(w, "%s%v", sep, node.value)
+		n += int64(m)
+		if nil != err {
+			return n, err
+		}
+		sep = " "
+	}
+	return n, nil
+}
+
+// StringFunc returns a string representation of the immutable treap,
+// rendering each value in order with format and joining them with sep.
+//
+func (t *T) StringFunc(format func(interface{}) string, sep string) string {
+	var b strings.Builder
+	w := t.walk()
+	for n, first := w.next(), true; nil != n; n, first = w.next(), false {
+		if !first {
+			b.WriteString(sep)
+		}
+		b.WriteString(format(n.value))
+	}
+	return b.String()
+}
+
 func sum(a, b *T) (count int) {
 	if nil != a {
 		count += a.count
@@ -327,3 +1251,86 @@ func sum(a, b *T) (count int) {
 	}
 	return count
 }
+
+func weigh(a, b *T) (weight int) {
+	if nil != a {
+		weight += a.weight
+	}
+	if nil != b {
+		weight += b.weight
+	}
+	return weight
+}
+
+// Return true iff the value of node a sorts before the value of node b.
+//
+func nodeLess(a, b *T) bool {
+	if a.score < b.score {
+		return true
+	}
+	if b.score < a.score {
+		return false
+	}
+	less, _ := a.fnScore(a.value)
+	return less(a.value, b.value)
+}
+
+// Return true iff the value of node t sorts before value, which has
+// score s and comparison function less.
+//
+func (t *T) precedes(value interface{}, s float64, less func(a, b interface{}) bool) bool {
+	return t.score < s || s == t.score && less(t.value, value)
+}
+
+// Return true iff the value of node t sorts after value, which has
+// score s and comparison function less.
+//
+func (t *T) follows(value interface{}, s float64, less func(a, b interface{}) bool) bool {
+	return s < t.score || s == t.score && less(value, t.value)
+}
+
+// An interval is the half-open range of values [lo,hi), with the score
+// and comparison function of each bound.
+//
+type interval struct {
+	lo, hi         interface{}
+	sLo, sHi       float64
+	lessLo, lessHi func(a, b interface{}) bool
+}
+
+// Return the interval [lo,hi) under the ordering of treap t.
+//
+func (t *T) newInterval(lo, hi interface{}) *interval {
+	lessLo, sLo := t.fnScore(lo)
+	lessHi, sHi := t.fnScore(hi)
+	return &interval{lo, hi, sLo, sHi, lessLo, lessHi}
+}
+
+// Return true iff no value lies in interval r.
+//
+func (r *interval) empty() bool {
+	return !(r.sLo < r.sHi || r.sLo == r.sHi && r.lessLo(r.lo, r.hi))
+}
+
+// Return true iff the value of node t is below interval r.
+//
+func (r *interval) below(t *T) bool { return t.precedes(r.lo, r.sLo, r.lessLo) }
+
+// Return true iff the value of node t is at or above the end of interval r.
+//
+func (r *interval) above(t *T) bool { return !t.precedes(r.hi, r.sHi, r.lessHi) }
+
+// Return the highest node of treap t with a value in interval r, or nil.
+//
+func (r *interval) top(t *T) *T {
+	for nil != t {
+		if r.below(t) {
+			t = t.right
+		} else if r.above(t) {
+			t = t.left
+		} else {
+			break
+		}
+	}
+	return t
+}
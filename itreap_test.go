@@ -1,7 +1,14 @@
 package itreap
 
 import (
+	"errors"
+	"fmt"
+	"github.com/glenn-brown/ordinal"
+	"hash/fnv"
 	"math/rand"
+	"reflect"
+	"runtime/debug"
+	"strings"
 	"testing"
 )
 
@@ -23,7 +30,7 @@ func (i *T) verifyCounts(t *testing.T) {
 		t.Errorf("%v != %v", i.count, s)
 	}
 	i.left.verifyCounts(t)
-	i.left.verifyCounts(t)
+	i.right.verifyCounts(t)
 }
 
 func TestT_Insert(t *testing.T) {
@@ -121,6 +128,16 @@ func TestT_RemoveN(s *testing.T) {
 	if t.Len() != 0 {
 		s.Error("t.Len() != 0")
 	}
+	t = itreap(3)
+	for _, n := range []int{-1, 3} {
+		if nu, val := t.RemoveN(n); nu != t || nil != val {
+			s.Error("RemoveN(", n, ") == ", nu, ", ", val)
+		}
+	}
+	greater := NewWithLess(func(a, b interface{}) bool { return a.(int) > b.(int) })
+	if nu, _ := greater.RemoveN(0); nu.Insert(1).Insert(2).String() != "2 1" {
+		s.Error("RemoveN of empty list lost the ordering")
+	}
 }
 
 func TestT_GetN(s *testing.T) {
@@ -131,6 +148,37 @@ func TestT_GetN(s *testing.T) {
 		if i != g {
 			s.Error(i, " != ", g)
 		}
+		if g := t.GetN(i - 100).(int); i != g {
+			s.Error(i-100, ": ", i, " != ", g)
+		}
+	}
+	if t.GetN(-1) != 99 || t.GetN(-100) != 0 {
+		s.Error("GetN(-1) == ", t.GetN(-1), ", GetN(-100) == ", t.GetN(-100))
+	}
+	for _, i := range []int{-101, 100, -1000} {
+		if g := t.GetN(i); nil != g {
+			s.Error("GetN(", i, ") == ", g)
+		}
+	}
+	if g := New().GetN(-1); nil != g {
+		s.Error("GetN(-1) of empty list == ", g)
+	}
+}
+
+func TestT_Contains(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	for v := 0; v < 100; v++ {
+		if !t.Contains(v) {
+			s.Error("!Contains(", v, ")")
+		}
+	}
+	if t.Contains(-1) || t.Contains(100) {
+		s.Error("Contains absent value")
+	}
+	// An empty list holds nothing, even of types ordinal cannot compare.
+	if New().Contains(struct{}{}) {
+		s.Error("empty list Contains a value")
 	}
 }
 
@@ -227,3 +275,1106 @@ func BenchmarkT_RemoveN_random(b *testing.B) {
 		t, _ = t.RemoveN(rand.Intn(i))
 	}
 }
+
+func TestT_StringFunc(s *testing.T) {
+	s.Parallel()
+	t := itreap(4)
+	g := t.StringFunc(func(v interface{}) string { return fmt.Sprintf("<%d>", v) }, ", ")
+	x := "<0>, <1>, <2>, <3>"
+	if g != x {
+		s.Error(g + " != " + x)
+	}
+	if g := New().StringFunc(func(interface{}) string { return "x" }, ","); g != "" {
+		s.Error(g + " != ")
+	}
+	id := func(v interface{}) string { return v.(string) }
+	if g := list("", "a", "b").StringFunc(id, ","); g != ",a,b" {
+		s.Error(g + " != ,a,b")
+	}
+	if g := list("", "").StringFunc(id, ","); g != "," {
+		s.Error(g + " != ,")
+	}
+}
+
+// A failWriter accepts n bytes and then fails.
+//
+type failWriter int
+
+func (f *failWriter) Write(p []byte) (int, error) {
+	if len(p) > int(*f) {
+		n := int(*f)
+		*f = 0
+		return n, errors.New("write failed")
+	}
+	*f -= failWriter(len(p))
+	return len(p), nil
+}
+
+func TestT_WriteTo(s *testing.T) {
+	s.Parallel()
+	t := list(3, 10, 1, 2)
+	var b strings.Builder
+	n, err := t.WriteTo(&b)
+	if nil != err || b.String() != t.String() || n != int64(b.Len()) {
+		s.Errorf("WriteTo == %q, %d, %v", b.String(), n, err)
+	}
+	f := failWriter(4)
+	if n, err := t.WriteTo(&f); nil == err || 4 != n {
+		s.Error("WriteTo to failing writer == ", n, ", ", err)
+	}
+	if n, err := New().WriteTo(&f); 0 != n || nil != err {
+		s.Error("WriteTo of empty list == ", n, ", ", err)
+	}
+}
+
+func TestT_LongestConsecutive(s *testing.T) {
+	s.Parallel()
+	less := NewWithLess(func(a, b interface{}) bool { return a.(int) < b.(int) })
+	cases := []struct {
+		t             *T
+		start, length int
+	}{
+		{New(), 0, 0},
+		{list(7), 7, 1},
+		{list(1, 2, 3, 5, 6, 7, 8, 10), 5, 4},
+		{list(-3, -2, -1, 4, 5), -3, 3},
+		{list(1, 2, 2, 3, 9), 1, 3},
+		{list(1, 3, 5), 1, 1},
+		{list(1.5, 2.5, 3.5), 0, 0},
+		{less.Insert(1).Insert(3).Insert(2), 1, 3},
+		{list(5, 6, 7).Reverse(), 5, 3},
+		{list(1, 2, 4, 5, 6, 9).Reverse(), 4, 3},
+		{list(1, 2, 3, 2).Reverse(), 1, 3},
+	}
+	for _, c := range cases {
+		start, length := c.t.LongestConsecutive()
+		if start != c.start || length != c.length {
+			s.Errorf("%v: (%d,%d) != (%d,%d)", c.t, start, length, c.start, c.length)
+		}
+	}
+}
+
+func TestT_Uncons(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	for i := 0; i < 100; i++ {
+		head, tail, ok := t.Uncons()
+		if !ok || head != i {
+			s.Error(head, " != ", i)
+		}
+		if tail.Len() != 99-i {
+			s.Error(tail.Len(), " != ", 99-i)
+		}
+		t = tail
+		t.verifyCounts(s)
+	}
+	if _, _, ok := t.Uncons(); ok {
+		s.Error("Uncons of empty list")
+	}
+}
+
+func TestT_MinMax(s *testing.T) {
+	s.Parallel()
+	if _, ok := New().Min(); ok {
+		s.Error("Min of empty list")
+	}
+	if _, ok := New().Max(); ok {
+		s.Error("Max of empty list")
+	}
+	for n := 1; n < 50; n++ {
+		t := New()
+		for _, v := range rand.Perm(1000)[:n] {
+			t = t.Insert(v)
+		}
+		if min, ok := t.Min(); !ok || min != t.GetN(0) {
+			s.Error(min, " != ", t.GetN(0))
+		}
+		if max, ok := t.Max(); !ok || max != t.GetN(t.Len()-1) {
+			s.Error(max, " != ", t.GetN(t.Len()-1))
+		}
+	}
+}
+
+func TestT_Get(s *testing.T) {
+	s.Parallel()
+	stored := &MyType{1, 2}
+	t := list(&MyType{0, 1}, stored, &MyType{3, 4})
+	g, found := t.Get(&MyType{2, 1})
+	if !found || g != stored {
+		s.Error("Get == ", g, ", ", found)
+	}
+	if g, found := t.Get(&MyType{2, 2}); found || nil != g {
+		s.Error("Get of absent value == ", g, ", ", found)
+	}
+	if g, found := list(5, 7).Get(7); !found || 7 != g {
+		s.Error("Get(7) == ", g, ", ", found)
+	}
+	if _, found := New().Get(7); found {
+		s.Error("Get in empty list")
+	}
+}
+
+func TestT_Rank(s *testing.T) {
+	s.Parallel()
+	t := New()
+	for _, v := range rand.Perm(200)[:100] {
+		t = t.Insert(v)
+	}
+	walked := 0
+	for i := t.Iter(); ; walked++ {
+		v, ok := i.Next()
+		if !ok {
+			break
+		}
+		if r := t.Rank(v); r != walked || t.GetN(r) != v {
+			s.Error(v, " has rank ", r, " not ", walked)
+		}
+	}
+	if r := t.Rank(1000); r != t.Len() {
+		s.Error(r, " != ", t.Len())
+	}
+	if r := t.Rank(-1); r != 0 {
+		s.Error(r, " != 0")
+	}
+	if r := list(1, 2, 2, 2, 3).Rank(2); r != 1 {
+		s.Error(r, " != 1")
+	}
+}
+
+func TestT_RangeCount(s *testing.T) {
+	s.Parallel()
+	in := rand.Perm(300)[:100]
+	t := New()
+	for _, v := range in {
+		t = t.Insert(v)
+	}
+	t = t.Insert(in[0]).Insert(in[1])
+	in = append(in, in[0], in[1])
+	for i := 0; i < 500; i++ {
+		lo, hi := rand.Intn(320)-10, rand.Intn(320)-10
+		x := 0
+		for _, v := range in {
+			if lo <= v && v < hi {
+				x++
+			}
+		}
+		if g := t.RangeCount(lo, hi); g != x {
+			s.Errorf("RangeCount(%d, %d) == %d, want %d", lo, hi, g, x)
+		}
+	}
+	if g := t.RangeCount(5, 5); g != 0 {
+		s.Error(g, " != 0")
+	}
+	if g := New().RangeCount(0, 5); g != 0 {
+		s.Error(g, " != 0")
+	}
+}
+
+func TestT_AnyInRange(s *testing.T) {
+	s.Parallel()
+	t := list(10, 20, 20, 30)
+	cases := []struct {
+		lo, hi int
+		x      bool
+	}{
+		{10, 11, true},
+		{11, 20, false},
+		{21, 30, false},
+		{15, 25, true},
+		{0, 10, false},
+		{0, 11, true},
+		{30, 99, true},
+		{31, 99, false},
+		{0, 99, true},
+		{20, 20, false},
+		{30, 10, false},
+	}
+	for _, c := range cases {
+		if g := t.AnyInRange(c.lo, c.hi); g != c.x {
+			s.Errorf("AnyInRange(%d, %d) == %v", c.lo, c.hi, g)
+		}
+		if g := t.RangeCount(c.lo, c.hi); (0 < g) != c.x {
+			s.Errorf("RangeCount(%d, %d) == %d", c.lo, c.hi, g)
+		}
+	}
+	if New().AnyInRange(0, 99) {
+		s.Error("AnyInRange of empty list")
+	}
+}
+
+func TestT_ToSlice(s *testing.T) {
+	s.Parallel()
+	if g := New().ToSlice(); nil == g || 0 != len(g) {
+		s.Error(g)
+	}
+	g := itreap(100).ToSlice()
+	if len(g) != 100 {
+		s.Error(len(g), " != 100")
+	}
+	for i, v := range g {
+		if v != i {
+			s.Error(v, " != ", i)
+		}
+	}
+}
+
+// Return true iff treaps a and b have identical shapes, values, and priorities.
+//
+func sameShape(a, b *T) bool {
+	if nil == a || nil == b {
+		return a == b
+	}
+	return a.value == b.value && a.priority == b.priority && a.count == b.count &&
+		sameShape(a.left, b.left) && sameShape(a.right, b.right)
+}
+
+func TestT_InsertRand(s *testing.T) {
+	s.Parallel()
+	in := rand.Perm(100)
+	ra, rb := rand.New(rand.NewSource(42)), rand.New(rand.NewSource(42))
+	a, b := New(), New()
+	for _, v := range in {
+		a, b = a.InsertRand(ra, v), b.InsertRand(rb, v)
+	}
+	if !sameShape(a, b) {
+		s.Error("trees from the same seed differ")
+	}
+	if a.String() != itreap(100).String() {
+		s.Error(a)
+	}
+	c, rc := New(), rand.New(rand.NewSource(43))
+	for _, v := range in {
+		c = c.InsertRand(rc, v)
+	}
+	if sameShape(a, c) {
+		s.Error("trees from different seeds match")
+	}
+}
+
+func TestT_PredecessorSuccessor(s *testing.T) {
+	s.Parallel()
+	t := list(10, 20, 20, 30, 40)
+	cases := []struct {
+		v          int
+		pred, succ interface{}
+	}{
+		{20, 10, 30},
+		{25, 20, 30},
+		{10, nil, 20},
+		{40, 30, nil},
+		{5, nil, 10},
+		{45, 40, nil},
+	}
+	for _, c := range cases {
+		p, ok := t.Predecessor(c.v)
+		if p != c.pred || ok != (nil != c.pred) {
+			s.Errorf("Predecessor(%d) == %v, %v", c.v, p, ok)
+		}
+		n, ok := t.Successor(c.v)
+		if n != c.succ || ok != (nil != c.succ) {
+			s.Errorf("Successor(%d) == %v, %v", c.v, n, ok)
+		}
+	}
+	if _, ok := New().Predecessor(1); ok {
+		s.Error("Predecessor in empty list")
+	}
+	if _, ok := New().Successor(1); ok {
+		s.Error("Successor in empty list")
+	}
+}
+
+func TestT_FloorCeiling(s *testing.T) {
+	s.Parallel()
+	t := list(10, 20, 20, 30, 40)
+	cases := []struct {
+		v              int
+		floor, ceiling interface{}
+	}{
+		{20, 20, 20},
+		{10, 10, 10},
+		{40, 40, 40},
+		{25, 20, 30},
+		{5, nil, 10},
+		{45, 40, nil},
+	}
+	for _, c := range cases {
+		f, ok := t.Floor(c.v)
+		if f != c.floor || ok != (nil != c.floor) {
+			s.Errorf("Floor(%d) == %v, %v", c.v, f, ok)
+		}
+		g, ok := t.Ceiling(c.v)
+		if g != c.ceiling || ok != (nil != c.ceiling) {
+			s.Errorf("Ceiling(%d) == %v, %v", c.v, g, ok)
+		}
+	}
+}
+
+func TestT_InsertUnique(s *testing.T) {
+	s.Parallel()
+	t, added := itreap(10).InsertUnique(10)
+	if !added || t.Len() != 11 {
+		s.Error("InsertUnique(10) == ", t, added)
+	}
+	nu, added := t.InsertUnique(10)
+	if added || nu != t {
+		s.Error("second InsertUnique(10) == ", nu, added)
+	}
+	nu, added = t.InsertUnique(5)
+	if added || nu.Len() != 11 {
+		s.Error("InsertUnique(5) == ", nu, added)
+	}
+	keys := []MyType{{1, 2}, {0, 3}}
+	u, _ := New().InsertUnique(&keys[0])
+	if u, added = u.InsertUnique(&keys[1]); added || u.Len() != 1 {
+		s.Error("InsertUnique of equal Slow value == ", u, added)
+	}
+}
+
+func TestT_Count(s *testing.T) {
+	s.Parallel()
+	t := itreap(20)
+	for k := 1; k <= 5; k++ {
+		t = t.Insert(7)
+		if g := t.Count(7); g != k+1 {
+			s.Error(g, " != ", k+1)
+		}
+	}
+	if g := t.Count(8); g != 1 {
+		s.Error(g, " != 1")
+	}
+	for _, v := range []int{-1, 20, 100} {
+		if g := t.Count(v); g != 0 {
+			s.Error(g, " != 0")
+		}
+	}
+	if g := New().Count(1); g != 0 {
+		s.Error(g, " != 0")
+	}
+}
+
+func TestT_EqualRange(s *testing.T) {
+	s.Parallel()
+	for k := 0; k < 4; k++ {
+		t := list(1, 3, 3, 9)
+		for i := 0; i < k; i++ {
+			t = t.Insert(5)
+		}
+		lo, hi := t.EqualRange(5)
+		if lo != 3 || hi-lo != k || hi-lo != t.Count(5) {
+			s.Errorf("EqualRange(5) with %d copies == %d, %d", k, lo, hi)
+		}
+		if 0 < k && lo != t.IndexOf(5) {
+			s.Error(lo, " != ", t.IndexOf(5))
+		}
+		for _, v := range t.SliceN(lo, hi) {
+			if 5 != v {
+				s.Error(t.SliceN(lo, hi))
+			}
+		}
+	}
+	if lo, hi := list(1, 3, 3, 9).EqualRange(3); lo != 1 || hi != 3 {
+		s.Error("EqualRange(3) == ", lo, ", ", hi)
+	}
+	if lo, hi := New().EqualRange(3); lo != 0 || hi != 0 {
+		s.Error("EqualRange(3) of empty list == ", lo, ", ", hi)
+	}
+}
+
+func TestT_RemoveAll(s *testing.T) {
+	s.Parallel()
+	for k := 0; k < 4; k++ {
+		t := itreap(20).Remove(7)
+		for i := 0; i < k; i++ {
+			t = t.Insert(7)
+		}
+		nu, removed := t.RemoveAll(7)
+		if removed != k {
+			s.Error(removed, " != ", k)
+		}
+		if 0 == k && nu != t {
+			s.Error("RemoveAll of absent value copied the tree")
+		}
+		if nu.Len() != 19 || nu.Contains(7) {
+			s.Error(nu)
+		}
+		if err := nu.VerifyAugmented(); nil != err {
+			s.Error(err)
+		}
+		if t.Len() != 19+k {
+			s.Error("original modified")
+		}
+	}
+}
+
+func TestT_RemoveSlice(s *testing.T) {
+	s.Parallel()
+	t := list(1, 2, 2, 3, 5)
+	nu, removed := t.RemoveSlice([]interface{}{2, 4, 2, 2, 5, 9})
+	if removed != 3 || nu.String() != "1 3" {
+		s.Error(nu, ", ", removed)
+	}
+	if err := nu.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	if t.String() != "1 2 2 3 5" {
+		s.Error("original modified")
+	}
+	if nu, removed := t.RemoveSlice([]interface{}{0, 4}); nu != t || 0 != removed {
+		s.Error("RemoveSlice of absent values == ", nu, ", ", removed)
+	}
+	if nu, removed := t.RemoveSlice(t.ToSlice()); nil != nu || 5 != removed {
+		s.Error("RemoveSlice of every value == ", nu, ", ", removed)
+	}
+}
+
+func TestT_SliceN(s *testing.T) {
+	s.Parallel()
+	t := itreap(50)
+	for n := 0; n < 100; n++ {
+		i, j := rand.Intn(60)-5, rand.Intn(60)-5
+		g := t.SliceN(i, j)
+		if nil == g {
+			s.Error("SliceN returned nil")
+		}
+		k := max(i, 0)
+		for _, v := range g {
+			if v != t.GetN(k) {
+				s.Errorf("SliceN(%d, %d) == %v", i, j, g)
+				break
+			}
+			k++
+		}
+		if k != max(min(j, 50), max(i, 0)) {
+			s.Errorf("SliceN(%d, %d) has %d values", i, j, len(g))
+		}
+	}
+}
+
+func TestT_GetNFloatInt(s *testing.T) {
+	s.Parallel()
+	f := list(2.5, 0.5, 1.5)
+	if v, ok := f.GetNFloat(0); !ok || 0.5 != v {
+		s.Error("GetNFloat(0) == ", v, ", ", ok)
+	}
+	if v, ok := f.GetNFloat(-1); !ok || 2.5 != v {
+		s.Error("GetNFloat(-1) == ", v, ", ", ok)
+	}
+	i := itreap(3)
+	if v, ok := i.GetNInt(2); !ok || 2 != v {
+		s.Error("GetNInt(2) == ", v, ", ", ok)
+	}
+	if _, ok := f.GetNInt(0); ok {
+		s.Error("GetNInt of float64")
+	}
+	if _, ok := i.GetNFloat(0); ok {
+		s.Error("GetNFloat of int")
+	}
+	if _, ok := i.GetNInt(3); ok {
+		s.Error("GetNInt(3) out of range")
+	}
+	if _, ok := f.GetNFloat(-4); ok {
+		s.Error("GetNFloat(-4) out of range")
+	}
+}
+
+func TestT_GetMany(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	indices := []int{42, 3, 99, 17, -1, 100, 3, 0}
+	want := []interface{}{42, 3, 99, 17, nil, nil, 3, 0}
+	if g := t.GetMany(indices); fmt.Sprint(g) != fmt.Sprint(want) {
+		s.Error(g, " != ", want)
+	}
+	if g := t.GetMany(nil); 0 != len(g) {
+		s.Error(g)
+	}
+	if g := New().GetMany([]int{0}); nil != g[0] {
+		s.Error(g)
+	}
+}
+
+// 1000 lookups in a large tree, in one call and one at a time.  Widely
+// scattered positions share little of their descents; a page of
+// adjacent positions shares most.
+//
+func BenchmarkT_GetMany(b *testing.B) {
+	b.StopTimer()
+	t := itreap(100000)
+	indices := rand.Perm(100000)[:1000]
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		t.GetMany(indices)
+	}
+}
+
+func BenchmarkT_GetMany_GetN(b *testing.B) {
+	b.StopTimer()
+	t := itreap(100000)
+	indices := rand.Perm(100000)[:1000]
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		for _, n := range indices {
+			t.GetN(n)
+		}
+	}
+}
+
+func BenchmarkT_GetMany_page(b *testing.B) {
+	b.StopTimer()
+	t := itreap(100000)
+	indices := rand.Perm(1000)
+	for i := range indices {
+		indices[i] += 5000
+	}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		t.GetMany(indices)
+	}
+}
+
+func BenchmarkT_GetMany_pageGetN(b *testing.B) {
+	b.StopTimer()
+	t := itreap(100000)
+	indices := rand.Perm(1000)
+	for i := range indices {
+		indices[i] += 5000
+	}
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		for _, n := range indices {
+			t.GetN(n)
+		}
+	}
+}
+
+func TestT_MustGetN(s *testing.T) {
+	s.Parallel()
+	t := itreap(5)
+	if g := t.MustGetN(4); 4 != g {
+		s.Error(g, " != 4")
+	}
+	if nu, v := t.MustRemoveN(0); 0 != v || nu.Len() != 4 {
+		s.Error("MustRemoveN(0) == ", nu, ", ", v)
+	}
+	cases := []struct {
+		n int
+		x string
+	}{
+		{7, "itreap: index 7 out of range [0,5)"},
+		{5, "itreap: index 5 out of range [0,5)"},
+		{-1, "itreap: index -1 out of range [0,5)"},
+	}
+	for _, c := range cases {
+		for name, fn := range map[string]func(){
+			"MustGetN":    func() { t.MustGetN(c.n) },
+			"MustRemoveN": func() { t.MustRemoveN(c.n) },
+		} {
+			func() {
+				defer func() {
+					if g := recover(); g != c.x {
+						s.Errorf("%s(%d) panicked with %v", name, c.n, g)
+					}
+				}()
+				fn()
+			}()
+		}
+	}
+}
+
+func TestT_GetWeighted(s *testing.T) {
+	s.Parallel()
+	t := New()
+	for _, v := range rand.Perm(5) {
+		t = t.InsertWeighted(v, v) // 0 has no weight
+	}
+	t = t.Insert(9)
+	want := []int{1, 2, 2, 3, 3, 3, 4, 4, 4, 4, 9}
+	if t.TotalWeight() != len(want) {
+		s.Error(t.TotalWeight(), " != ", len(want))
+	}
+	for n, v := range want {
+		if g := t.GetWeighted(n); v != g {
+			s.Errorf("GetWeighted(%d) == %v, want %d", n, g, v)
+		}
+	}
+	if nil != t.GetWeighted(-1) || nil != t.GetWeighted(len(want)) {
+		s.Error("GetWeighted out of range")
+	}
+	if err := t.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	// Weights follow their values through removals and splits.
+	t = t.Remove(3)
+	left, right := t.Split(2)
+	if t.TotalWeight() != 8 || left.TotalWeight() != 1 || right.TotalWeight() != 7 {
+		s.Error(t.TotalWeight(), left.TotalWeight(), right.TotalWeight())
+	}
+	for _, x := range []*T{t, left, right, left.Join(right), left.Union(right)} {
+		if err := x.VerifyAugmented(); nil != err {
+			s.Error(err)
+		}
+	}
+	if g := right.GetWeighted(4); 4 != g {
+		s.Error(g, " != 4")
+	}
+	if itreap(10).TotalWeight() != 10 || New().TotalWeight() != 0 {
+		s.Error("TotalWeight of unweighted list")
+	}
+	bad := &T{1, 5, 1, 1, nil, nil, nil, 2, 3}
+	if err := bad.VerifyAugmented(); nil == err || !strings.Contains(err.Error(), "weight") {
+		s.Error(err)
+	}
+}
+
+func TestT_Quantile(s *testing.T) {
+	s.Parallel()
+	t := itreap(1000)
+	cases := []struct {
+		q    float64
+		want int
+	}{
+		{0.5, 499},
+		{0.99, 989},
+		{0.95, 949},
+		{0, 0},
+		{1, 999},
+		{-1, 0},
+		{2, 999},
+		{0.0001, 0},
+	}
+	for _, c := range cases {
+		if g := t.Quantile(c.q); g != c.want {
+			s.Errorf("Quantile(%v) == %v, want %d", c.q, g, c.want)
+		}
+	}
+	if g := list(7).Quantile(0.5); 7 != g {
+		s.Error(g, " != 7")
+	}
+	if g := New().Quantile(0.5); nil != g {
+		s.Error(g, " != nil")
+	}
+}
+
+func TestT_RandomSample(s *testing.T) {
+	s.Parallel()
+	r := rand.New(rand.NewSource(1))
+	t := itreap(10)
+	const draws = 30000
+	hits := make([]int, 10)
+	for n := 0; n < draws; n++ {
+		g := t.RandomSample(r, 3)
+		if len(g) != 3 || g[0] == g[1] || g[0] == g[2] || g[1] == g[2] {
+			s.Fatal("RandomSample(3) == ", g)
+		}
+		for _, v := range g {
+			hits[v.(int)]++
+		}
+	}
+	// Each value is expected in 3/10 of the draws, give or take 5%.
+	for v, h := range hits {
+		if h < draws*3/10*95/100 || draws*3/10*105/100 < h {
+			s.Errorf("%d sampled %d times in %d draws", v, h, draws)
+		}
+	}
+	if g := t.RandomSample(r, 10); len(g) != 10 {
+		s.Error("RandomSample(10) == ", g)
+	}
+	if g := t.RandomSample(r, 0); nil == g || 0 != len(g) {
+		s.Error("RandomSample(0) == ", g)
+	}
+}
+
+func TestT_GetRangeByValue(s *testing.T) {
+	s.Parallel()
+	x, t := multiset(200, 100)
+	for n := 0; n < 100; n++ {
+		lo, hi := rand.Intn(110)-5, rand.Intn(110)-5
+		want := []interface{}{}
+		for _, v := range x {
+			if lo <= v && v < hi {
+				want = append(want, v)
+			}
+		}
+		g := t.GetRangeByValue(lo, hi)
+		if fmt.Sprint(g) != fmt.Sprint(want) {
+			s.Errorf("GetRangeByValue(%d, %d) == %v, want %v", lo, hi, g, want)
+		}
+	}
+	if g := t.GetRangeByValue(50, 50); nil == g || 0 != len(g) {
+		s.Error("GetRangeByValue(50, 50) == ", g)
+	}
+}
+
+func TestT_NewWithLess(s *testing.T) {
+	s.Parallel()
+	greater := func(a, b interface{}) bool { return a.(int) > b.(int) }
+	t := NewWithLess(greater)
+	if t.Len() != 0 || t.String() != "" || t.Contains(1) {
+		s.Error("NewWithLess is not empty: ", t)
+	}
+	for _, v := range rand.Perm(10) {
+		t = t.Insert(v)
+	}
+	if g := t.String(); g != "9 8 7 6 5 4 3 2 1 0" {
+		s.Error(g)
+	}
+	if err := t.Verify(); nil != err {
+		s.Error(err)
+	}
+	for v := 0; v < 10; v++ {
+		if !t.Contains(v) {
+			s.Error("!Contains(", v, ")")
+		}
+	}
+	if t.Contains(10) {
+		s.Error("Contains(10)")
+	}
+	for _, v := range rand.Perm(10) {
+		t = t.Remove(v)
+		if t.Contains(v) || nil == t {
+			s.Error("Remove(", v, ") == ", t)
+		}
+	}
+	if t.Len() != 0 || t.String() != "" {
+		s.Error("not empty after removals: ", t)
+	}
+	t = t.Insert(1).Insert(3).Insert(2)
+	if g := t.String(); g != "3 2 1" {
+		s.Error("comparator lost after emptying: ", g)
+	}
+
+	// Values that the ordinal package cannot order are fine with a less function.
+	type key struct{ k int }
+	u := NewWithLess(func(a, b interface{}) bool { return a.(key).k < b.(key).k })
+	u = u.Insert(key{2}).Insert(key{1})
+	if g := u.String(); g != "{1} {2}" {
+		s.Error(g)
+	}
+}
+
+func TestNewHashed(s *testing.T) {
+	s.Parallel()
+	hash := func(v interface{}) int32 {
+		h := fnv.New32a()
+		fmt.Fprint(h, v)
+		return int32(h.Sum32())
+	}
+	a, b := NewHashed(hash), NewHashed(hash)
+	for _, v := range rand.Perm(100) {
+		a = a.Insert(v)
+	}
+	for _, v := range rand.Perm(100) {
+		b = b.Insert(v)
+	}
+	if err := a.Verify(); nil != err {
+		s.Error(err)
+	}
+	if a.Shape() != b.Shape() {
+		s.Error("shape depends on insertion order")
+	}
+	if a.priority != hash(a.value) {
+		s.Error("priority ", a.priority, " != hash ", hash(a.value))
+	}
+	// Emptied lists keep the hash.
+	c := a.RemoveRangeN(0, 100).Insert(7)
+	if c.priority != hash(7) {
+		s.Error("emptied list lost its hash")
+	}
+}
+
+func TestT_PopMinMax(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	for i := 0; i < 50; i++ {
+		var min, max interface{}
+		var ok bool
+		if min, t, ok = t.PopMin(); !ok || min != i {
+			s.Error(min, " != ", i)
+		}
+		if max, t, ok = t.PopMax(); !ok || max != 99-i {
+			s.Error(max, " != ", 99-i)
+		}
+		t.verifyCounts(s)
+	}
+	if _, _, ok := t.PopMin(); ok {
+		s.Error("PopMin of empty list")
+	}
+	if _, _, ok := t.PopMax(); ok {
+		s.Error("PopMax of empty list")
+	}
+}
+
+// An entry is ordered by its key alone, and carries data.
+type entry struct{ key, data int }
+
+func (a *entry) Less(b interface{}) bool { return a.key < b.(*entry).key }
+
+func TestT_Replace(s *testing.T) {
+	s.Parallel()
+	t := New()
+	for _, k := range rand.Perm(20) {
+		t = t.Insert(&entry{k, 0})
+	}
+	nu, replaced := t.Replace(&entry{7, 1})
+	if !replaced {
+		s.Error("Replace of present key failed")
+	}
+	if !sameShapeExcept(t, nu, 7) {
+		s.Error("Replace changed the shape of the tree")
+	}
+	for i := 0; i < 20; i++ {
+		e := nu.GetN(i).(*entry)
+		x := 0
+		if 7 == i {
+			x = 1
+		}
+		if e.key != i || e.data != x {
+			s.Error(e, " at ", i)
+		}
+		if t.GetN(i).(*entry).data != 0 {
+			s.Error("original modified")
+		}
+	}
+	if u, replaced := t.Replace(&entry{20, 1}); replaced || u != t {
+		s.Error("Replace of absent key")
+	}
+}
+
+// Return true iff treaps a and b differ only in the value with key k.
+//
+func sameShapeExcept(a, b *T, k int) bool {
+	if nil == a || nil == b {
+		return a == b
+	}
+	if a.priority != b.priority || a.count != b.count ||
+		a.value.(*entry).key != b.value.(*entry).key ||
+		a.value != b.value && a.value.(*entry).key != k {
+		return false
+	}
+	return sameShapeExcept(a.left, b.left, k) && sameShapeExcept(a.right, b.right, k)
+}
+
+func TestT_IndexOf(s *testing.T) {
+	s.Parallel()
+	t := New()
+	for _, v := range rand.Perm(200)[:100] {
+		t = t.Insert(v)
+	}
+	for v := 0; v < 200; v++ {
+		i := t.IndexOf(v)
+		if t.Contains(v) != (-1 != i) {
+			s.Error("IndexOf(", v, ") == ", i)
+		}
+		if -1 != i && t.GetN(i) != v {
+			s.Error(t.GetN(i), " != ", v)
+		}
+	}
+	if i := list(1, 2, 2, 2, 3).IndexOf(2); i != 1 {
+		s.Error(i, " != 1")
+	}
+	if i := New().IndexOf(0); i != -1 {
+		s.Error(i, " != -1")
+	}
+}
+
+// Deep trees, such as those from unlucky priorities, must not grow the
+// stack while inserting or removing.
+//
+func TestT_Insert_deep(s *testing.T) {
+	const n = 1000000
+	nodes := make([]*T, n)
+	for i := range nodes {
+		nodes[i] = &T{1, int32(n - i), i, float64(i), nil, nil, nil, 1, 1}
+	}
+	t := build(nodes) // a right spine n deep
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+	t = t.insertPriority(n, 0, 1)
+	if t.Len() != n+1 || t.last().value != n {
+		s.Error("Insert into deep tree")
+	}
+	t = t.Remove(n)
+	if t.Len() != n || t.Contains(n) {
+		s.Error("Remove from deep tree")
+	}
+	if testing.Short() {
+		return
+	}
+	t = itreap(n)
+	if err := t.Verify(); nil != err {
+		s.Error(err)
+	}
+}
+
+func TestT_Insert_cached(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	if nil == t.cfg || t.cfg.typ != reflect.TypeOf(0) {
+		s.Fatal("no cached type after inserting ints")
+	}
+	// Values of other types resolve their own comparison.
+	less, score := t.fnScore("b")
+	if _, want := ordinal.FnScore("b"); score != want || !less("a", "b") {
+		s.Error("string compared as cached int")
+	}
+	for i := 0; i < 100; i++ {
+		t = t.Remove(i)
+	}
+	if nil != t {
+		s.Error(t, " != nil")
+	}
+}
+
+// The cached score of each type must match ordinal's, since trees mix
+// nodes scored both ways.
+//
+func TestScores(s *testing.T) {
+	s.Parallel()
+	values := []interface{}{
+		int(-7), int(0), int(1 << 40),
+		int32(-7), int32(0), int32(1 << 30),
+		int64(-7), int64(0), int64(1 << 60),
+		float32(-2.5), float32(0), float32(1e30),
+		float64(-2.5), float64(0), float64(1e300),
+	}
+	tested := map[reflect.Type]bool{}
+	for _, v := range values {
+		_, want := ordinal.FnScore(v)
+		if g := cache(v).score(v); g != want {
+			s.Errorf("%T %v: score %v, ordinal %v", v, v, g, want)
+		}
+		tested[reflect.TypeOf(v)] = true
+	}
+	for typ := range scores {
+		if !tested[typ] {
+			s.Error("untested score type ", typ)
+		}
+	}
+}
+
+// Inserting 1e6 ints with and without the cached comparison:
+//
+//	go test -bench 'Insert_(cached|uncached)' -benchtime 1000000x
+//
+func BenchmarkT_Insert_cached(b *testing.B) {
+	b.StopTimer()
+	in := rand.Perm(b.N)
+	t := New()
+	b.StartTimer()
+	for _, v := range in {
+		t = t.Insert(v)
+	}
+}
+
+func BenchmarkT_Insert_uncached(b *testing.B) {
+	b.StopTimer()
+	in := rand.Perm(b.N)
+	t := New()
+	b.StartTimer()
+	for _, v := range in {
+		less, score := ordinal.FnScore(v)
+		t = t.insert(&T{1, priority(), v, score, nil, nil, nil, 1, 1}, less)
+	}
+}
+
+// Goroutines inserting into their own trees, with priorities from the
+// pooled sources and from the global source.
+//
+func BenchmarkT_Insert_parallel(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		t := New()
+		for i := 0; pb.Next(); i++ {
+			t = t.Insert(i)
+		}
+	})
+}
+
+func BenchmarkT_Insert_parallelGlobal(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		t := New()
+		for i := 0; pb.Next(); i++ {
+			t = t.insertPriority(i, rand.Int31(), 1)
+		}
+	})
+}
+
+func BenchmarkT_Insert_recursive(b *testing.B) {
+	b.StopTimer()
+	in := rand.Perm(b.N)
+	t := New()
+	b.StartTimer()
+	for _, v := range in {
+		less, score := ordinal.FnScore(v)
+		t = t.insertRecursive(&T{1, rand.Int31(), v, score, nil, nil, nil, 1, 1}, less)
+	}
+}
+
+func BenchmarkT_Remove_recursive(b *testing.B) {
+	b.StopTimer()
+	t := itreap(b.N)
+	out := rand.Perm(b.N)
+	b.StartTimer()
+	for _, v := range out {
+		less, score := ordinal.FnScore(v)
+		t, _ = t.removeRecursive(v, score, less)
+	}
+}
+
+// The recursive insert and remove that preceded the iterative ones, kept
+// to benchmark against.
+
+func (t *T) insertRecursive(nu *T, less func(a, b interface{}) bool) *T {
+	if nil == t {
+		return nu
+	}
+
+	// Insert on left if less than root, and right if greater, taking care to
+	// handle score cases first for performance.
+
+	if nu.score < t.score {
+		goto left
+	}
+	if t.score < nu.score || less(t.value, nu.value) {
+		right := t.right.insertRecursive(nu, less)
+		if right.priority > t.priority {
+			// Rotate left, replacing t.right with right.
+			return &T{
+				t.count + 1, right.priority, right.value, right.score,
+				&T{1 + sum(t.left, right.left), t.priority, t.value, t.score, t.left, right.left, t.cfg,
+					t.own, t.own + weigh(t.left, right.left)},
+				right.right, right.cfg, right.own, t.weight + nu.own}
+		}
+		return &T{t.count + 1, t.priority, t.value, t.score, t.left, right, t.cfg, t.own, t.weight + nu.own}
+	}
+left:
+	left := t.left.insertRecursive(nu, less)
+	if left.priority > t.priority {
+		// Rotate right, replacing t.left with left.
+		return &T{
+			t.count + 1, left.priority, left.value, left.score, left.left,
+			&T{1 + sum(left.right, t.right), t.priority, t.value, t.score,
+				left.right, t.right, t.cfg, t.own, t.own + weigh(left.right, t.right)},
+			left.cfg, left.own, t.weight + nu.own}
+	}
+	return &T{t.count + 1, t.priority, t.value, t.score, left, t.right, t.cfg, t.own, t.weight + nu.own}
+}
+
+func (t *T) removeRecursive(value interface{}, score float64, less func(a, b interface{}) bool) (*T, bool) {
+	if nil == t {
+		return nil, false
+	}
+	if score < t.score {
+		goto left
+	}
+	if t.score < score || less(t.value, value) {
+		right, ok := t.right.removeRecursive(value, score, less)
+		return &T{t.count - 1, t.priority, t.value, t.score, t.left, right, t.cfg,
+			t.own, t.own + weigh(t.left, right)}, ok
+	}
+	if !less(value, t.value) {
+		return t.removeNode(), true
+	}
+left:
+	left, ok := t.left.removeRecursive(value, score, less)
+	return &T{t.count - 1, t.priority, t.value, t.score, left, t.right, t.cfg,
+		t.own, t.own + weigh(left, t.right)}, ok
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+// IsInterleaved returns true iff the merged sorted order of a and b
+// strictly alternates between values of a and values of b, with no two
+// consecutive values from the same tree, in O(M+N) time.  Trees whose
+// sizes differ by more than one cannot alternate, so false is returned
+// without walking them.  Two empty trees, or an empty tree and a tree
+// with a single value, are trivially interleaved.  Equal values may be
+// merged in any order, so a run of equal values alternates if it holds
+// as many values from each tree, or one more from the tree not supplying
+// the value before the run.
+//
+func IsInterleaved(a, b *T) bool {
+	m, n := a.Len(), b.Len()
+	if m > n+1 || n > m+1 {
+		return false
+	}
+	// Equal values may be ordered freely, so each run of equal values is
+	// taken as a block.  A block holding as many values of a as of b
+	// alternates starting from either tree, ending opposite its start,
+	// so it leaves the tree of the last value unchanged.  A block with
+	// one more value from one tree must start and end with that tree.
+	wa, wb := a.walk(), b.walk()
+	x, y := wa.next(), wb.next()
+	var prev *walker
+	for nil != x || nil != y {
+		v := x
+		if nil == x || nil != y && nodeLess(y, x) {
+			v = y
+		}
+		m, n := 0, 0
+		for ; nil != x && !nodeLess(v, x); x = wa.next() {
+			m++
+		}
+		for ; nil != y && !nodeLess(v, y); y = wb.next() {
+			n++
+		}
+		switch {
+		case m == n:
+		case m == n+1 && prev != wa:
+			prev = wa
+		case n == m+1 && prev != wb:
+			prev = wb
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// SymmetricDifferenceCount returns the number of values in exactly one
+// of a and b, without building the difference, in O(M+N) time.  Equal
+// values are matched one for one, so a value stored twice in a and once
+// in b is counted once.
+//
+func SymmetricDifferenceCount(a, b *T) int {
+	wa, wb := a.walk(), b.walk()
+	x, y := wa.next(), wb.next()
+	count := 0
+	for nil != x && nil != y {
+		switch {
+		case nodeLess(x, y):
+			count++
+			x = wa.next()
+		case nodeLess(y, x):
+			count++
+			y = wb.next()
+		default:
+			x, y = wa.next(), wb.next()
+		}
+	}
+	for ; nil != x; x = wa.next() {
+		count++
+	}
+	for ; nil != y; y = wb.next() {
+		count++
+	}
+	return count
+}
+
+// Diff compares the list with an older version, old, in O(M+N) time,
+// returning the values in the list but not in old, and the values in
+// old but not in the list, each in order.  Equal values are matched one
+// for one, so a value stored twice in the list and once in old is added
+// once.
+//
+func (t *T) Diff(old *T) (added, removed []interface{}) {
+	wa, wb := t.walk(), old.walk()
+	x, y := wa.next(), wb.next()
+	for nil != x && nil != y {
+		switch {
+		case nodeLess(x, y):
+			added = append(added, x.value)
+			x = wa.next()
+		case nodeLess(y, x):
+			removed = append(removed, y.value)
+			y = wb.next()
+		default:
+			x, y = wa.next(), wb.next()
+		}
+	}
+	for ; nil != x; x = wa.next() {
+		added = append(added, x.value)
+	}
+	for ; nil != y; y = wb.next() {
+		removed = append(removed, y.value)
+	}
+	return added, removed
+}
+
+// Union returns a new treap holding the values of both the list and
+// other, in O(M*log(N/M)) expected time for lists of sizes M <= N.  Like
+// Insert, Union keeps equal values from both lists, so the result has
+// t.Len()+other.Len() values.  Both originals are unchanged.
+//
+func (t *T) Union(other *T) *T {
+	if nil == t.root() {
+		return t.rooted(other.root())
+	}
+	if nil == other.root() {
+		return t
+	}
+	return union(t, other)
+}
+
+func union(a, b *T) *T {
+	if nil == a {
+		return b
+	}
+	if nil == b {
+		return a
+	}
+	if a.priority < b.priority {
+		a, b = b, a
+	}
+	less, _ := a.fnScore(a.value)
+	l, r := b.split(a.value, a.score, less)
+	left, right := union(a.left, l), union(a.right, r)
+	return &T{1 + sum(left, right), a.priority, a.value, a.score, left, right, a.cfg,
+		a.own, a.own + weigh(left, right)}
+}
+
+// Intersection returns a new treap holding the values of the list that
+// are also in other.  A value stored m times in the list and n times in
+// other is kept min(m,n) times, and the kept copies are those of the
+// list.  Subtrees of the list are shared with the result where possible,
+// so the intersection of a list with itself is the list.
+//
+func (t *T) Intersection(other *T) *T {
+	if nil == other.root() {
+		return t.rooted(nil)
+	}
+	return t.rooted(t.root().combine(other.root(), func(m, n int) int {
+		if m < n {
+			return m
+		}
+		return n
+	}))
+}
+
+// Difference returns a new treap holding the values of the list that
+// are not in other.  A value stored m times in the list and n times in
+// other is kept max(m-n,0) times.  Subtrees of the list are shared with
+// the result where possible, so removing a few values copies only the
+// O(log(N)) nodes above each.
+//
+func (t *T) Difference(other *T) *T {
+	if nil == other.root() {
+		return t
+	}
+	return t.rooted(t.root().combine(other.root(), func(m, n int) int {
+		if m < n {
+			return 0
+		}
+		return m - n
+	}))
+}
+
+// Return a treap of the values of t and other, keeping keep(m,n) copies
+// of each value stored m times in t and n times in other, where the
+// copies are those of t.  Where other is empty, keep(m,0) must be 0 or
+// m, and subtrees of t are dropped or returned whole.  The root value
+// of t splits other into the lesser, equal, and greater values, which
+// are combined with the children of t; a root whose value t holds only
+// once is reused when both children come back unchanged.  A root value
+// held several times splits t too, and its copies are counted together.
+//
+func (t *T) combine(other *T, keep func(m, n int) int) *T {
+	if nil == t {
+		return nil
+	}
+	if nil == other {
+		if 0 == keep(1, 0) {
+			return nil
+		}
+		return t
+	}
+	less, _ := t.fnScore(t.value)
+	ol, rest := other.split(t.value, t.score, less)
+	oe, og := rest.splitAfter(t.value, t.score, less)
+	if t.unique() {
+		left, right := t.left.combine(ol, keep), t.right.combine(og, keep)
+		switch {
+		case 0 == keep(1, oe.Len()):
+			return join(left, right)
+		case left == t.left && right == t.right:
+			return t
+		}
+		return &T{1 + sum(left, right), t.priority, t.value, t.score, left, right, t.cfg,
+			t.own, t.own + weigh(left, right)}
+	}
+	lesser, lo := t.left.split(t.value, t.score, less)
+	hi, greater := t.right.splitAfter(t.value, t.score, less)
+	equal := join(join(lo, &T{1, t.priority, t.value, t.score, nil, nil, t.cfg, t.own, t.own}), hi)
+	equal, _ = equal.splitN(keep(equal.Len(), oe.Len()))
+	return join(join(lesser.combine(ol, keep), equal), greater.combine(og, keep))
+}
+
+// Return true iff no child of node t holds a value equal to its own.
+//
+func (t *T) unique() bool {
+	return (nil == t.left || nodeLess(t.left.last(), t)) &&
+		(nil == t.right || nodeLess(t, t.right.first()))
+}
+
+// Equal returns true iff the list and other hold equal values in the
+// same order, regardless of the shapes of their trees, in O(N) time.
+// Values a and b are equal if neither is less than the other.
+//
+func (t *T) Equal(other *T) bool {
+	if t.Len() != other.Len() {
+		return false
+	}
+	wa, wb := t.walk(), other.walk()
+	for x, y := wa.next(), wb.next(); nil != x; x, y = wa.next(), wb.next() {
+		if nodeLess(x, y) || nodeLess(y, x) {
+			return false
+		}
+	}
+	return true
+}
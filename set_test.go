@@ -0,0 +1,227 @@
+package itreap
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func list(values ...interface{}) *T {
+	rv := New()
+	for _, v := range values {
+		rv = rv.Insert(v)
+	}
+	return rv
+}
+
+func TestIsInterleaved(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		a, b *T
+		x    bool
+	}{
+		{New(), New(), true},
+		{list(1), New(), true},
+		{New(), list(1), true},
+		{list(1, 2), New(), false},
+		{list(0, 2, 4), list(1, 3), true},
+		{list(1, 3), list(0, 2, 4), true},
+		{list(0, 2), list(1, 3), true},
+		{list(0, 1), list(2, 3), false},
+		{list(0, 2, 4, 6), list(1, 3), false},
+		{list(1, 3), list(1, 3), true},
+		{list(1, 1), list(0, 2), false},
+		{list(1), list(1, 2), true},
+		{list(1, 2), list(1), true},
+		{list(1, 3), list(1, 2), true},
+		{list(1, 2), list(1, 3), true},
+		{list(0, 1, 2), list(1, 3), false},
+		{list(0, 1, 3), list(1, 2), true},
+		{list(1, 1), list(1), true},
+		{list(1, 1, 1), list(1), false},
+		{list(0, 1), list(1, 2), true},
+		{list(1, 2), list(0, 1), true},
+		{list(0, 1), list(1, 1, 2), false},
+		{list(1, 1, 2), list(0, 1), false},
+	}
+	for i, c := range cases {
+		if g := IsInterleaved(c.a, c.b); g != c.x {
+			t.Errorf("case %d: IsInterleaved(%v, %v) == %v", i, c.a, c.b, g)
+		}
+	}
+}
+
+func TestSymmetricDifferenceCount(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		a, b *T
+		x    int
+	}{
+		{New(), New(), 0},
+		{itreap(10), itreap(10), 0},
+		{list(0, 1, 2), list(3, 4), 5},
+		{New(), list(3, 4), 2},
+		{list(0, 1, 2, 3), list(2, 3, 4), 3},
+		{list(1, 1, 2), list(1, 2, 2), 2},
+	}
+	for i, c := range cases {
+		if g := SymmetricDifferenceCount(c.a, c.b); g != c.x {
+			t.Errorf("case %d: SymmetricDifferenceCount(%v, %v) == %d, want %d", i, c.a, c.b, g, c.x)
+		}
+	}
+}
+
+func TestT_Diff(t *testing.T) {
+	t.Parallel()
+	old := itreap(50).Insert(20)
+	nu := old.Remove(7).Remove(20).Remove(33).Insert(-1).Insert(12).Insert(99)
+	added, removed := nu.Diff(old)
+	if fmt.Sprint(added) != "[-1 12 99]" || fmt.Sprint(removed) != "[7 20 33]" {
+		t.Error(added, removed)
+	}
+	if added, removed := old.Diff(nu); fmt.Sprint(added) != "[7 20 33]" || fmt.Sprint(removed) != "[-1 12 99]" {
+		t.Error(added, removed)
+	}
+	if added, removed := old.Diff(old); nil != added || nil != removed {
+		t.Error(added, removed)
+	}
+	if added, removed := New().Diff(list(1, 1)); nil != added || fmt.Sprint(removed) != "[1 1]" {
+		t.Error(added, removed)
+	}
+}
+
+func TestT_Union(t *testing.T) {
+	t.Parallel()
+	for i := 0; i < 20; i++ {
+		var x []int
+		a, b := New(), New()
+		for _, v := range rand.Perm(100)[:rand.Intn(100)] {
+			a = a.Insert(v)
+			x = append(x, v)
+		}
+		for _, v := range rand.Perm(100)[:rand.Intn(100)] {
+			b = b.Insert(v)
+			x = append(x, v)
+		}
+		sort.Ints(x)
+		u := a.Union(b)
+		if err := u.VerifyAugmented(); nil != err {
+			t.Error(err)
+		}
+		if u.Len() != len(x) {
+			t.Errorf("Len() == %d, want %d", u.Len(), len(x))
+		}
+		for j, v := range x {
+			if g := u.GetN(j); g != v {
+				t.Errorf("GetN(%d) == %v, want %d", j, g, v)
+			}
+		}
+	}
+}
+
+// Return a sorted multiset of n random values from [0,max), and the
+// corresponding treap.
+//
+func multiset(n, max int) ([]int, *T) {
+	x := make([]int, n)
+	t := New()
+	for i := range x {
+		x[i] = rand.Intn(max)
+		t = t.Insert(x[i])
+	}
+	sort.Ints(x)
+	return x, t
+}
+
+func TestT_IntersectionDifference(t *testing.T) {
+	t.Parallel()
+	for i := 0; i < 50; i++ {
+		xa, a := multiset(rand.Intn(60), 30)
+		xb, b := multiset(rand.Intn(60), 30)
+		counts := map[int]int{}
+		for _, v := range xb {
+			counts[v]++
+		}
+		var xi, xd []int
+		for _, v := range xa {
+			if 0 < counts[v] {
+				counts[v]--
+				xi = append(xi, v)
+			} else {
+				xd = append(xd, v)
+			}
+		}
+		for _, c := range []struct {
+			name string
+			g    *T
+			x    []int
+		}{
+			{"Intersection", a.Intersection(b), xi},
+			{"Difference", a.Difference(b), xd},
+		} {
+			if err := c.g.VerifyAugmented(); nil != err {
+				t.Error(c.name, err)
+			}
+			if c.g.Len() != len(c.x) {
+				t.Errorf("%s: Len() == %d, want %d", c.name, c.g.Len(), len(c.x))
+				continue
+			}
+			for j, v := range c.x {
+				if g := c.g.GetN(j); g != v {
+					t.Errorf("%s: GetN(%d) == %v, want %d", c.name, j, g, v)
+				}
+			}
+		}
+		if a.Len() != len(xa) || b.Len() != len(xb) {
+			t.Error("original modified")
+		}
+	}
+	if nil != New().Intersection(itreap(3)) || nil != itreap(3).Intersection(New()) {
+		t.Error("Intersection with empty list")
+	}
+	if a := itreap(3); a.Difference(New()) != a {
+		t.Error("Difference with empty list")
+	}
+	big := itreap(1000)
+	if g := big.SharedNodes(big.Intersection(big)); g != 1000 {
+		t.Errorf("Intersection with itself shares %d of 1000 nodes", g)
+	}
+	if g := big.SharedNodes(big.Difference(list(5000))); g < 1000-big.Height() {
+		t.Errorf("Difference with disjoint list shares %d of 1000 nodes, height %d", g, big.Height())
+	}
+	if g := big.SharedNodes(big.Difference(list(500))); g < 999-big.Height() {
+		t.Errorf("Difference removing one value shares %d of 999 nodes, height %d", g, big.Height())
+	}
+	dups := list(1, 2, 2, 2, 3)
+	if g := dups.Intersection(list(2, 2)); g.String() != "2 2" {
+		t.Error(g)
+	}
+	if g := dups.Difference(list(2, 3)); g.String() != "1 2 2" {
+		t.Error(g)
+	}
+}
+
+func TestT_Equal(t *testing.T) {
+	t.Parallel()
+	a, b := itreap(100), itreap(100)
+	if sameShape(a, b) {
+		t.Error("independently built trees have the same shape")
+	}
+	if !a.Equal(b) || !b.Equal(a) {
+		t.Error("equal lists compare unequal")
+	}
+	if !New().Equal(New()) || New().Equal(a) || a.Equal(New()) {
+		t.Error("empty list comparisons")
+	}
+	if c := a.Remove(50).Insert(150); a.Equal(c) || c.Equal(a) {
+		t.Error("lists differing by one value compare equal")
+	}
+	if a.Equal(a.Remove(99)) {
+		t.Error("lists of different lengths compare equal")
+	}
+	keys := []MyType{{1, 2}, {0, 3}}
+	if !list(&keys[0]).Equal(list(&keys[1])) {
+		t.Error("values equal under Less compare unequal")
+	}
+}
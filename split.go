@@ -0,0 +1,232 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+import "fmt"
+
+// Split treap t by position into a treap of its first n values and a
+// treap of the rest, in O(log(N)) time.  Both share structure with t.
+//
+func (t *T) splitN(n int) (left, right *T) {
+	if nil == t || n <= 0 {
+		return nil, t
+	}
+	if t.count <= n {
+		return t, nil
+	}
+	lcount := t.left.Len()
+	if n <= lcount {
+		l, r := t.left.splitN(n)
+		return l, &T{1 + sum(r, t.right), t.priority, t.value, t.score, r, t.right, t.cfg,
+			t.own, t.own + weigh(r, t.right)}
+	}
+	l, r := t.right.splitN(n - lcount - 1)
+	return &T{1 + sum(t.left, l), t.priority, t.value, t.score, t.left, l, t.cfg,
+		t.own, t.own + weigh(t.left, l)}, r
+}
+
+// Take returns a treap of the first n values of the list, in O(log(N))
+// time, sharing structure with the original.  If n <= 0 the result is
+// empty, and if n >= t.Len() it is the original list.
+//
+func (t *T) Take(n int) *T {
+	left, _ := t.root().splitN(n)
+	return t.rooted(left)
+}
+
+// Drop returns a treap of the values of the list after the first n, in
+// O(log(N)) time, sharing structure with the original.  If n <= 0 the
+// result is the original list, and if n >= t.Len() it is empty.
+//
+func (t *T) Drop(n int) *T {
+	_, right := t.root().splitN(n)
+	return t.rooted(right)
+}
+
+// Split returns a treap of the values in the list that are less than
+// value and a treap of the rest, in O(log(N)) time.  Both share
+// structure with the original, which is unchanged.
+//
+func (t *T) Split(value interface{}) (left, right *T) {
+	less, score := t.fnScore(value)
+	left, right = t.root().split(value, score, less)
+	return t.rooted(left), t.rooted(right)
+}
+
+func (t *T) split(value interface{}, score float64, less func(a, b interface{}) bool) (left, right *T) {
+	if nil == t {
+		return nil, nil
+	}
+	if t.precedes(value, score, less) {
+		l, r := t.right.split(value, score, less)
+		return &T{1 + sum(t.left, l), t.priority, t.value, t.score, t.left, l, t.cfg,
+			t.own, t.own + weigh(t.left, l)}, r
+	}
+	l, r := t.left.split(value, score, less)
+	return l, &T{1 + sum(r, t.right), t.priority, t.value, t.score, r, t.right, t.cfg,
+		t.own, t.own + weigh(r, t.right)}
+}
+
+// Split treap t into a treap of its values no greater than value,
+// which has the given score and less function, and a treap of the rest.
+//
+func (t *T) splitAfter(value interface{}, score float64, less func(a, b interface{}) bool) (left, right *T) {
+	if nil == t {
+		return nil, nil
+	}
+	if !t.follows(value, score, less) {
+		l, r := t.right.splitAfter(value, score, less)
+		return &T{1 + sum(t.left, l), t.priority, t.value, t.score, t.left, l, t.cfg,
+			t.own, t.own + weigh(t.left, l)}, r
+	}
+	l, r := t.left.splitAfter(value, score, less)
+	return l, &T{1 + sum(r, t.right), t.priority, t.value, t.score, r, t.right, t.cfg,
+		t.own, t.own + weigh(r, t.right)}
+}
+
+// Join returns the concatenation of the list and other, in O(log(N))
+// time.  Every value in the list must be no greater than every value
+// in other; Join compares the greatest and least values at the seam and
+// panics if they overlap, rather than build an out-of-order treap.
+//
+func (t *T) Join(other *T) *T {
+	a, b := t.root(), other.root()
+	if nil != a && nil != b && nodeLess(b.first(), a.last()) {
+		panic(fmt.Sprintf("itreap: Join of overlapping lists: %v > %v",
+			a.last().value, b.first().value))
+	}
+	return t.rooted(join(a, b))
+}
+
+// Concat returns the concatenation of the trees, in O(K*log(N)) time
+// for K trees, sharing structure with each.  Every value in each tree
+// must be no greater than every value in the trees after it.  Like
+// Join, Concat checks the seam between successive nonempty trees and
+// panics if they overlap; each check costs only O(log(N)).
+//
+func Concat(trees ...*T) *T {
+	var t *T
+	for i, u := range trees {
+		if 0 == i {
+			t = u
+		} else {
+			t = t.Join(u)
+		}
+	}
+	return t
+}
+
+// Return the concatenation of treaps a and b, where every value in a
+// must sort no later than every value in b, in O(log(N)) time.
+//
+func join(a, b *T) *T {
+	if nil == a {
+		return b
+	}
+	if nil == b {
+		return a
+	}
+	if a.priority > b.priority {
+		return &T{a.count + b.count, a.priority, a.value, a.score, a.left, join(a.right, b), a.cfg,
+			a.own, a.weight + b.weight}
+	}
+	return &T{a.count + b.count, b.priority, b.value, b.score, join(a, b.left), b.right, b.cfg,
+		b.own, a.weight + b.weight}
+}
+
+// Return a function that yields the values of treap t in order until
+// the yield function returns false.
+//
+func (t *T) seq() func(yield func(interface{}) bool) {
+	return func(yield func(interface{}) bool) {
+		w := t.walk()
+		for n := w.next(); nil != n; n = w.next() {
+			if !yield(n.value) {
+				return
+			}
+		}
+	}
+}
+
+// RemoveNRangeIter removes the values at positions [i,j) from the list
+// in O(log(N)) time, returning the modified list and a function that
+// lazily yields the removed values in order.  The removed values remain
+// in a valid immutable treap until the function is discarded.  Indices
+// are clamped to [0,t.Len()], and if i >= j the original list is
+// returned with a function that yields nothing.
+//
+func (t *T) RemoveNRangeIter(i, j int) (nu *T, removed func(yield func(interface{}) bool)) {
+	nu, cut := t.cutN(i, j)
+	return nu, cut.seq()
+}
+
+// RemoveRangeN returns a new list like the original, but with the values
+// at positions [i,j) removed, in O(log(N)) time.  Indices are clamped to
+// [0,t.Len()], and if i >= j the original list is returned.
+//
+func (t *T) RemoveRangeN(i, j int) *T {
+	nu, _ := t.cutN(i, j)
+	return nu
+}
+
+// CutN removes the values at positions [i,j) from the list in
+// O(log(N)+j-i) time, returning the modified list and the removed
+// values in order.  Indices are clamped to [0,t.Len()], and if i >= j
+// the original list is returned with an empty slice.  The original
+// list is unchanged.
+//
+func (t *T) CutN(i, j int) (nu *T, cut []interface{}) {
+	nu, c := t.cutN(i, j)
+	return nu, c.ToSlice()
+}
+
+// Split the values at positions [i,j) out of treap t, with indices
+// clamped to [0,t.Len()], returning treaps of the remaining values and
+// of the cut values.  If i >= j, t is returned uncut.
+//
+func (t *T) cutN(i, j int) (nu, cut *T) {
+	if i < 0 {
+		i = 0
+	}
+	if j > t.Len() {
+		j = t.Len()
+	}
+	if i >= j {
+		return t, nil
+	}
+	left, rest := t.splitN(i)
+	cut, right := rest.splitN(j - i)
+	return t.rooted(join(left, right)), cut
+}
+
+// InsertN returns a new list like the original, but with value
+// inserted at position n, in O(log(N)) time, so that GetN(n) returns
+// value.  n is clamped to [0,t.Len()].  InsertN ignores the order of
+// values, treating the treap as a positional list, so the values need
+// not be comparable.  Mixing InsertN with sorted operations such as
+// Insert, Remove, or Contains on the same list is unsupported.
+//
+func (t *T) InsertN(n int, value interface{}) *T {
+	left, right := t.root().splitN(n)
+	nu := &T{1, t.newPriority(value), value, 0, nil, nil, t.config(), 1, 1}
+	return join(join(left, nu), right)
+}
+
+// Rotate returns a new list like the original, but cyclically shifted
+// so the value at position k becomes the value at position 0, in
+// O(log(N)) time.  Negative and out-of-range k are taken modulo
+// t.Len().  Rotate applies only to positional lists, because the
+// shifted values of a sorted list are no longer in sorted order.
+//
+func (t *T) Rotate(k int) *T {
+	n := t.Len()
+	if 0 == n {
+		return t
+	}
+	k = (k%n + n) % n
+	if 0 == k {
+		return t
+	}
+	left, right := t.splitN(k)
+	return join(right, left)
+}
@@ -0,0 +1,250 @@
+package itreap
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestT_RemoveNRangeIter(s *testing.T) {
+	s.Parallel()
+	t := itreap(20)
+	cases := []struct{ i, j, lo, hi int }{
+		{5, 10, 5, 10},
+		{0, 20, 0, 20},
+		{-3, 4, 0, 4},
+		{15, 99, 15, 20},
+		{7, 7, 7, 7},
+		{9, 3, 9, 9},
+	}
+	for _, c := range cases {
+		nu, removed := t.RemoveNRangeIter(c.i, c.j)
+		x := t
+		for k := c.lo; k < c.hi; k++ {
+			x, _ = x.RemoveN(c.lo)
+		}
+		if nu.String() != x.String() {
+			s.Errorf("[%d,%d): %v != %v", c.i, c.j, nu, x)
+		}
+		nu.verifyCounts(s)
+		k := c.lo
+		removed(func(v interface{}) bool {
+			if v != t.GetN(k) {
+				s.Errorf("[%d,%d): removed %v != %v", c.i, c.j, v, t.GetN(k))
+			}
+			k++
+			return true
+		})
+		if k != c.hi {
+			s.Errorf("[%d,%d): removed %d values", c.i, c.j, k-c.lo)
+		}
+	}
+	if t.Len() != 20 {
+		s.Error("original modified")
+	}
+}
+
+func TestT_Rotate(s *testing.T) {
+	s.Parallel()
+	t := itreap(10)
+	for _, k := range []int{0, 1, 3, 9, 10, 13, -1, -12} {
+		r := t.Rotate(k)
+		r.verifyCounts(s)
+		if r.Len() != 10 {
+			s.Errorf("Rotate(%d).Len() == %d", k, r.Len())
+		}
+		for i := 0; i < 10; i++ {
+			x := ((i+k)%10 + 10) % 10
+			if g := r.GetN(i); g != x {
+				s.Errorf("Rotate(%d).GetN(%d) == %v, want %d", k, i, g, x)
+			}
+		}
+	}
+	if nil != New().Rotate(3) {
+		s.Error("Rotate of empty list")
+	}
+}
+
+func TestT_Split(s *testing.T) {
+	s.Parallel()
+	t := New()
+	for _, v := range []int{0, 2, 4, 4, 6, 8} {
+		t = t.Insert(v)
+	}
+	cases := []struct {
+		v           int
+		left, right string
+	}{
+		{4, "0 2", "4 4 6 8"},
+		{5, "0 2 4 4", "6 8"},
+		{-1, "", "0 2 4 4 6 8"},
+		{9, "0 2 4 4 6 8", ""},
+	}
+	for _, c := range cases {
+		left, right := t.Split(c.v)
+		if left.String() != c.left || right.String() != c.right {
+			s.Errorf("Split(%d) == %v | %v", c.v, left, right)
+		}
+		if left.Len()+right.Len() != t.Len() {
+			s.Errorf("Split(%d) lengths %d + %d", c.v, left.Len(), right.Len())
+		}
+		for _, x := range []*T{left, right, left.Join(right)} {
+			if err := x.VerifyAugmented(); nil != err {
+				s.Error(err)
+			}
+		}
+		if g := left.Join(right).String(); g != t.String() {
+			s.Error(g + " != " + t.String())
+		}
+	}
+	if t.String() != "0 2 4 4 6 8" {
+		s.Error("original modified")
+	}
+}
+
+func TestT_Join(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	for i := -1; i <= 100; i++ {
+		left, right := t.Split(i)
+		j := left.Join(right)
+		if j.String() != t.String() {
+			s.Errorf("Split(%d) then Join: %v", i, j)
+		}
+		if err := j.VerifyAugmented(); nil != err {
+			s.Error(err)
+		}
+	}
+	if g := list(1, 2).Join(list(2, 3)).String(); g != "1 2 2 3" {
+		s.Error(g)
+	}
+	defer func() {
+		if nil == recover() {
+			s.Error("Join of overlapping lists did not panic")
+		}
+	}()
+	list(1, 5).Join(list(3, 7))
+}
+
+func TestT_RemoveRangeN(s *testing.T) {
+	s.Parallel()
+	t := itreap(50)
+	for n := 0; n < 100; n++ {
+		i, j := rand.Intn(60)-5, rand.Intn(60)-5
+		x := t
+		for k := i; k < j && k < 50; k++ {
+			if 0 <= k {
+				x, _ = x.RemoveN(max(i, 0))
+			}
+		}
+		g := t.RemoveRangeN(i, j)
+		if err := g.VerifyAugmented(); nil != err {
+			s.Error(err)
+		}
+		if g.String() != x.String() {
+			s.Errorf("RemoveRangeN(%d, %d) == %v, want %v", i, j, g, x)
+		}
+	}
+	if t.RemoveRangeN(5, 5) != t || t.RemoveRangeN(7, 2) != t {
+		s.Error("RemoveRangeN of empty range copied the tree")
+	}
+}
+
+func TestT_TakeDrop(s *testing.T) {
+	s.Parallel()
+	t := itreap(30)
+	for n := -2; n <= 32; n++ {
+		take, drop := t.Take(n), t.Drop(n)
+		k := min(max(n, 0), 30)
+		if take.Len() != k || drop.Len() != 30-k {
+			s.Errorf("Take/Drop(%d) lengths %d + %d", n, take.Len(), drop.Len())
+		}
+		if g := take.Join(drop).String(); g != t.String() {
+			s.Errorf("Take(%d) + Drop(%d) == %v", n, n, g)
+		}
+		for _, x := range []*T{take, drop} {
+			if err := x.VerifyAugmented(); nil != err {
+				s.Error(err)
+			}
+		}
+	}
+	if nil != t.Take(0) || t.Drop(0) != t || t.Take(30) != t || nil != t.Drop(30) {
+		s.Error("Take/Drop edge cases")
+	}
+}
+
+func TestT_InsertN(s *testing.T) {
+	s.Parallel()
+	t := New()
+	for _, c := range []struct {
+		n int
+		v string
+	}{{0, "c"}, {0, "a"}, {1, "b"}, {3, "e"}, {3, "d"}, {-4, "^"}, {99, "$"}} {
+		t = t.InsertN(c.n, c.v)
+		// The values are positional, so only the heap and counts hold.
+		if _, err := t.root().verifyAugmented(); nil != err {
+			s.Error(err)
+		}
+	}
+	if g := t.String(); g != "^ a b c d e $" {
+		s.Error(g)
+	}
+	if g := t.GetN(3); "c" != g {
+		s.Error(g, " != c")
+	}
+	// Values need not be comparable.
+	u := New().InsertN(0, struct{}{}).InsertN(0, []int{1})
+	if g := u.String(); g != "[1] {}" {
+		s.Error(g)
+	}
+}
+
+func TestT_CutN(s *testing.T) {
+	s.Parallel()
+	t := itreap(50)
+	for n := 0; n < 100; n++ {
+		i, j := rand.Intn(60)-5, rand.Intn(60)-5
+		nu, cut := t.CutN(i, j)
+		if fmt.Sprint(cut) != fmt.Sprint(t.SliceN(i, j)) {
+			s.Errorf("CutN(%d, %d) cut %v", i, j, cut)
+		}
+		if g := nu.String(); g != t.RemoveRangeN(i, j).String() {
+			s.Errorf("CutN(%d, %d) left %v", i, j, g)
+		}
+		if err := nu.VerifyAugmented(); nil != err {
+			s.Error(err)
+		}
+	}
+	if t.String() != itreap(50).String() {
+		s.Error("original modified")
+	}
+	if nu, cut := t.CutN(10, 5); nu != t || nil == cut || 0 != len(cut) {
+		s.Error("CutN(10, 5) == ", nu, ", ", cut)
+	}
+}
+
+func TestConcat(s *testing.T) {
+	s.Parallel()
+	t := itreap(30)
+	a, rest := t.Split(10)
+	b, c := rest.Split(20)
+	g := Concat(a, New(), b, c)
+	if g.String() != t.String() || g.Len() != 30 {
+		s.Error(g)
+	}
+	if err := g.VerifyAugmented(); nil != err {
+		s.Error(err)
+	}
+	if g := Concat(list(1, 2), list(2, 3), list(3)); g.String() != "1 2 2 3 3" {
+		s.Error(g)
+	}
+	if nil != Concat() || nil != Concat(nil, nil) {
+		s.Error("Concat of no values")
+	}
+	defer func() {
+		if nil == recover() {
+			s.Error("Concat of overlapping lists did not panic")
+		}
+	}()
+	Concat(a, c, b)
+}
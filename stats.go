@@ -0,0 +1,29 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+import "sync/atomic"
+
+// A Stats collects counts of the work done by treap operations, for
+// studying their behavior.  Counters are updated atomically, so a Stats
+// may be shared by goroutines and read with atomic.LoadUint64.
+//
+type Stats struct {
+	Rotations uint64 // rotations by Insert, Remove, and their relatives
+}
+
+var stats atomic.Pointer[Stats]
+
+// SetStats directs all later treap operations to count their work in
+// s, or to stop counting if s is nil.  With no Stats set, each rotation
+// costs one atomic load and nil check.
+//
+func SetStats(s *Stats) { stats.Store(s) }
+
+// Count one rotation in the current Stats, if any.
+//
+func rotated() {
+	if s := stats.Load(); nil != s {
+		atomic.AddUint64(&s.Rotations, 1)
+	}
+}
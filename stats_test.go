@@ -0,0 +1,33 @@
+package itreap
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Not parallel, since the Stats set is shared by all treaps.
+//
+func TestSetStats(s *testing.T) {
+	var st Stats
+	SetStats(&st)
+	defer SetStats(nil)
+	r := rand.New(rand.NewSource(1))
+	t := New()
+	for _, v := range r.Perm(100) {
+		t = t.InsertRand(r, v)
+	}
+	if st.Rotations != 159 {
+		s.Error(st.Rotations, " rotations inserting")
+	}
+	for v := 0; v < 100; v += 2 {
+		t = t.Remove(v)
+	}
+	if st.Rotations != 197 {
+		s.Error(st.Rotations, " rotations in all")
+	}
+	SetStats(nil)
+	t.Insert(1000).Remove(t.GetN(25))
+	if st.Rotations != 197 {
+		s.Error("rotations counted after SetStats(nil)")
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Verify checks the invariants of treap t: each node's count is one
+// more than the counts of its children, the values are in order, with
+// equal values permitted on either side of a node, and no node has a
+// higher priority than its parent.  It returns an error naming the
+// first violated invariant and the offending value, or nil, in O(N)
+// time.
+//
+func (t *T) Verify() error {
+	w := t.walk()
+	prev := w.next()
+	for n := w.next(); nil != n; prev, n = n, w.next() {
+		if nodeLess(n, prev) {
+			return fmt.Errorf("itreap: value %v is out of order after %v", n.value, prev.value)
+		}
+	}
+	_, err := t.root().verifyAugmented()
+	return err
+}
+
+// VerifyAugmented is like Verify, but also checks that the cached
+// aggregates of every node equal the values recomputed from its
+// subtree: the subtree count, which Verify already checks, and the
+// subtree weight.
+//
+func (t *T) VerifyAugmented() error {
+	if err := t.Verify(); nil != err {
+		return err
+	}
+	_, err := t.root().verifyWeight()
+	return err
+}
+
+// Return the recomputed weight of treap t, or an error for the first
+// node in t with an inconsistent weight.
+//
+func (t *T) verifyWeight() (weight int, err error) {
+	if nil == t {
+		return 0, nil
+	}
+	left, err := t.left.verifyWeight()
+	if nil != err {
+		return 0, err
+	}
+	right, err := t.right.verifyWeight()
+	if nil != err {
+		return 0, err
+	}
+	weight = t.own + left + right
+	if t.weight != weight {
+		return 0, fmt.Errorf("itreap: value %v has weight %d, want %d", t.value, t.weight, weight)
+	}
+	return weight, nil
+}
+
+// Return the recomputed count of treap t, or an error for the first
+// node in t with an inconsistent priority or count.
+//
+func (t *T) verifyAugmented() (count int, err error) {
+	if nil == t {
+		return 0, nil
+	}
+	left, err := t.left.verifyAugmented()
+	if nil != err {
+		return 0, err
+	}
+	right, err := t.right.verifyAugmented()
+	if nil != err {
+		return 0, err
+	}
+	if err := t.verifyPriority(); nil != err {
+		return 0, err
+	}
+	count = 1 + left + right
+	if t.count != count {
+		return 0, fmt.Errorf("itreap: value %v has count %d, want %d", t.value, t.count, count)
+	}
+	return count, nil
+}
+
+// VerifyHeap checks only the heap invariant of treap t: no node has a
+// higher priority than its parent.  It returns an error naming the
+// first offending value, or nil, in O(N) time.  Ordering and counts are
+// not checked, so it isolates the invariant that bulk construction is
+// most likely to break.
+//
+func (t *T) VerifyHeap() error {
+	w := t.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		if err := n.verifyPriority(); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// Return an error if a child of node t has a higher priority than t.
+//
+func (t *T) verifyPriority() error {
+	for _, c := range []*T{t.left, t.right} {
+		if nil != c && c.priority > t.priority {
+			return fmt.Errorf("itreap: value %v has priority %d above its parent %v with %d",
+				c.value, c.priority, t.value, t.priority)
+		}
+	}
+	return nil
+}
+
+// Height returns the number of nodes on the longest path from the root
+// of the treap to a leaf, or 0 for an empty treap, in O(N) time.
+//
+func (t *T) Height() int {
+	if nil == t.root() {
+		return 0
+	}
+	left, right := t.left.Height(), t.right.Height()
+	if left < right {
+		return 1 + right
+	}
+	return 1 + left
+}
+
+// Depth returns the number of nodes visited to find value in the treap,
+// counting the root as depth 1, in O(log(N)) time.  If the value is not
+// found, ok is false.
+//
+func (a *T) Depth(value interface{}) (depth int, ok bool) {
+	lessFn, s := a.fnScore(value)
+	for a = a.root(); nil != a; {
+		depth++
+		switch {
+		case a.precedes(value, s, lessFn):
+			a = a.right
+		case a.follows(value, s, lessFn):
+			a = a.left
+		default:
+			return depth, true
+		}
+	}
+	return 0, false
+}
+
+// Shape returns a diagnostic rendering of the structure of the treap,
+// in O(N) time.  Each node is shown as "(L (value p=priority c=count) R)",
+// where L and R are the shapes of its left and right subtrees, and an
+// empty treap is shown as "()".  Combined with InsertRand and a seeded
+// source, it pins the exact shape of a treap in tests.
+//
+func (t *T) Shape() string {
+	var b strings.Builder
+	t.root().shape(&b)
+	return b.String()
+}
+
+// Write the shape of treap t to b.
+//
+func (t *T) shape(b *strings.Builder) {
+	if nil == t {
+		b.WriteString("()")
+		return
+	}
+	b.WriteString("(")
+	t.left.shape(b)
+	fmt.Fprintf(b, " (%v p=%d c=%d) ", t.value, t.priority, t.count)
+	t.right.shape(b)
+	b.WriteString(")")
+}
+
+// SharedNodes returns the number of nodes reachable from both the treap
+// and other, by pointer identity, in O(N+M) time.  It measures the
+// structure shared by treaps derived from one another: after an
+// Insert, all but the O(log(N)) nodes on the rebuilt path are shared.
+//
+func (t *T) SharedNodes(other *T) int {
+	nodes := make(map[*T]bool, t.Len())
+	w := t.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		nodes[n] = true
+	}
+	shared := 0
+	w = other.walk()
+	for n := w.next(); nil != n; n = w.next() {
+		if nodes[n] {
+			shared++
+		}
+	}
+	return shared
+}
+
+// NodeCount returns the number of distinct nodes reachable from the
+// treap, by pointer identity, in O(N) time.  Within one treap no node
+// is reachable twice, so NodeCount equals Len; a difference reveals a
+// node wrongly shared within the treap.
+//
+func (t *T) NodeCount() int { return TotalNodes(t) }
+
+// TotalNodes returns the number of distinct nodes reachable from any of
+// the trees, by pointer identity, counting each node shared by several
+// trees once.  It measures the memory of versions derived from one
+// another: a tree of N values and the result of an Insert into it hold
+// about N+log(N) nodes together, not 2*N.  The cost is O(D) for D
+// distinct nodes, since a shared subtree is not descended twice.
+//
+func TotalNodes(trees ...*T) int {
+	nodes := make(map[*T]bool)
+	for _, t := range trees {
+		t.root().mark(nodes)
+	}
+	return len(nodes)
+}
+
+// Add node t and its descendants to nodes, skipping any subtree already
+// added.
+//
+func (t *T) mark(nodes map[*T]bool) {
+	if nil == t || nodes[t] {
+		return
+	}
+	nodes[t] = true
+	t.left.mark(nodes)
+	t.right.mark(nodes)
+}
@@ -0,0 +1,176 @@
+package itreap
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestT_Verify(s *testing.T) {
+	s.Parallel()
+	if err := New().Verify(); nil != err {
+		s.Error(err)
+	}
+	t := itreap(100)
+	if err := t.Verify(); nil != err {
+		s.Error(err)
+	}
+	for i := 0; i < 100; i += 7 {
+		t = t.Remove(i)
+		if err := t.Verify(); nil != err {
+			s.Error(err)
+		}
+	}
+
+	one := &T{1, 5, 1, 1, nil, nil, nil, 1, 1}
+	three := &T{1, 5, 3, 3, nil, nil, nil, 1, 1}
+	cases := []struct {
+		t *T
+		x string
+	}{
+		{&T{2, 10, 2, 2, three, nil, nil, 1, 2}, "out of order"},
+		{&T{2, 10, 2, 2, nil, one, nil, 1, 2}, "out of order"},
+		{&T{2, 4, 2, 2, one, nil, nil, 1, 2}, "priority"},
+		{&T{3, 10, 2, 2, one, nil, nil, 1, 3}, "count"},
+		{&T{3, 10, 2, 2, one, &T{2, 5, 3, 3, nil, nil, nil, 1, 2}, nil, 1, 3}, "count"},
+	}
+	for i, c := range cases {
+		err := c.t.Verify()
+		if nil == err || !strings.Contains(err.Error(), c.x) {
+			s.Errorf("case %d: %v", i, err)
+		}
+	}
+}
+
+func TestT_VerifyAugmented(s *testing.T) {
+	s.Parallel()
+	t := itreap(100)
+	for i := 0; i < 100; i += 7 {
+		t = t.Remove(i).Insert(i + 1)
+		if err := t.VerifyAugmented(); nil != err {
+			s.Error(err)
+		}
+	}
+	bad := &T{3, 10, 2, 2, &T{1, 5, 1, 1, nil, nil, nil, 1, 1}, nil, nil, 1, 3}
+	if err := bad.VerifyAugmented(); nil == err || !strings.Contains(err.Error(), "count") {
+		s.Error(err)
+	}
+}
+
+func TestT_VerifyHeap(s *testing.T) {
+	s.Parallel()
+	if err := itreap(100).VerifyHeap(); nil != err {
+		s.Error(err)
+	}
+	if err := New().VerifyHeap(); nil != err {
+		s.Error(err)
+	}
+	// Out of order and miscounted, but a valid heap.
+	ok := &T{7, 10, 2, 2, &T{1, 5, 3, 3, nil, nil, nil, 1, 1}, nil, nil, 1, 7}
+	if err := ok.VerifyHeap(); nil != err {
+		s.Error(err)
+	}
+	bad := &T{4, 10, 2, 2, nil, &T{3, 5, 4, 4, &T{1, 8, 3, 3, nil, nil, nil, 1, 1}, nil, nil, 1, 3}, nil, 1, 4}
+	err := bad.VerifyHeap()
+	if nil == err || !strings.Contains(err.Error(), "value 3 has priority 8") {
+		s.Error(err)
+	}
+}
+
+func TestT_Height(s *testing.T) {
+	s.Parallel()
+	if h := New().Height(); h != 0 {
+		s.Error(h, " != 0")
+	}
+	if h := list(1).Height(); h != 1 {
+		s.Error(h, " != 1")
+	}
+	t := itreap(1 << 16)
+	if h := t.Height(); h < 16 || 4*16 < h {
+		s.Error("Height() == ", h, " for 2^16 values")
+	}
+}
+
+func TestT_Depth(s *testing.T) {
+	s.Parallel()
+	t := itreap(1000)
+	if d, ok := t.Depth(t.value); !ok || d != 1 {
+		s.Error("root depth ", d, ok)
+	}
+	h := t.Height()
+	for i := 0; i < 1000; i++ {
+		if d, ok := t.Depth(i); !ok || d < 1 || h < d {
+			s.Error("Depth(", i, ") == ", d, ok)
+		}
+	}
+	if _, ok := t.Depth(1000); ok {
+		s.Error("Depth of absent value")
+	}
+}
+
+func TestT_Shape(s *testing.T) {
+	s.Parallel()
+	if g := New().Shape(); g != "()" {
+		s.Error(g)
+	}
+	r := rand.New(rand.NewSource(1))
+	t := New()
+	for _, v := range []int{2, 1, 3} {
+		t = t.InsertRand(r, v)
+	}
+	want := "(() (1 p=2019727887 c=3) " +
+		"((() (2 p=1298498081 c=1) ()) (3 p=1427131847 c=2) ()))"
+	if g := t.Shape(); g != want {
+		s.Error(g, " != ", want)
+	}
+}
+
+func TestT_SharedNodes(s *testing.T) {
+	s.Parallel()
+	t := itreap(1000)
+	if g := t.SharedNodes(t); g != 1000 {
+		s.Error(g, " != 1000")
+	}
+	for _, v := range []int{-1, 500, 1000} {
+		if g := t.SharedNodes(t.Insert(v)); g < t.Len()-t.Height() {
+			s.Errorf("Insert(%d) shares %d nodes, height %d", v, g, t.Height())
+		}
+	}
+	if g := t.SharedNodes(itreap(1000)); g != 0 {
+		s.Error(g, " != 0")
+	}
+	if g := New().SharedNodes(t); g != 0 {
+		s.Error(g, " != 0")
+	}
+}
+
+func TestT_NodeCount(s *testing.T) {
+	s.Parallel()
+	less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+	for _, t := range []*T{New(), itreap(1), itreap(1000), NewWithLess(less).Insert(1)} {
+		if g := t.NodeCount(); g != t.Len() {
+			s.Error(g, " != ", t.Len())
+		}
+	}
+}
+
+func TestTotalNodes(s *testing.T) {
+	s.Parallel()
+	t := itreap(1000)
+	u := t.Insert(500)
+	if g := TotalNodes(t, u); g > t.Len()+t.Height()+1 || g <= u.Len() {
+		s.Errorf("TotalNodes(t, t.Insert) == %d, height %d", g, t.Height())
+	}
+	if g := TotalNodes(t, u); g != t.Len()+u.Len()-t.SharedNodes(u) {
+		s.Error(g, " != ", t.Len()+u.Len()-t.SharedNodes(u))
+	}
+	if g := TotalNodes(t, t, nil); g != 1000 {
+		s.Error(g, " != 1000")
+	}
+	if g := TotalNodes(t, itreap(1000)); g != 2000 {
+		s.Error(g, " != 2000")
+	}
+	if g := TotalNodes(); g != 0 {
+		s.Error(g, " != 0")
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2012 by Glenn Brown.  All rights reserved.  See LICENSE.
+
+package itreap
+
+// A View is an indexable, read-only view of the values of an immutable
+// list in order, without copying them into a slice.  A View satisfies
+// sort.Interface for APIs that read one, such as sort.IsSorted, but
+// Swap panics, because the list cannot be modified.  Each access takes
+// O(log(N)) time, so sort.Search over a View takes O(log(N)^2) time.
+//
+type View struct {
+	t *T
+}
+
+// View returns a view of the values of the list.
+//
+func (t *T) View() View { return View{t} }
+
+// Len returns the number of values in the list.
+//
+func (v View) Len() int { return v.t.Len() }
+
+// At returns the value at position i of the list, which must be in the
+// interval [0,v.Len()).
+//
+func (v View) At(i int) interface{} { return v.t.getN(i) }
+
+// Less returns true iff the value at position i sorts before the value
+// at position j.  Both positions must be in the interval [0,v.Len()).
+//
+func (v View) Less(i, j int) bool { return nodeLess(v.t.nodeN(i), v.t.nodeN(j)) }
+
+// Swap panics, because the list is immutable.
+//
+func (v View) Swap(i, j int) { panic("itreap: Swap of immutable View") }
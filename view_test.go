@@ -0,0 +1,30 @@
+package itreap
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestT_View(s *testing.T) {
+	s.Parallel()
+	_, t := multiset(200, 50)
+	v := t.View()
+	if v.Len() != 200 || !sort.IsSorted(v) {
+		s.Error("View of sorted list is not sorted")
+	}
+	for x := -1; x <= 50; x++ {
+		i := sort.Search(v.Len(), func(i int) bool { return v.At(i).(int) >= x })
+		if j := t.IndexOf(x); -1 != j && i != j {
+			s.Errorf("Search for %d found %d, IndexOf == %d", x, i, j)
+		}
+		if i != t.Rank(x) {
+			s.Errorf("Search for %d found %d, Rank == %d", x, i, t.Rank(x))
+		}
+	}
+	defer func() {
+		if nil == recover() {
+			s.Error("Swap did not panic")
+		}
+	}()
+	v.Swap(0, 1)
+}